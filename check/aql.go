@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/jfrog/jfrog-cli-core/v2/common/spec"
+	servicesutils "github.com/jfrog/jfrog-client-go/artifactory/services/utils"
+	"github.com/orange-cloudfoundry/artifactory-resource/utils"
+)
+
+type aqlClause map[string]interface{}
+
+// aqlSpec builds a search spec running the aql query given in source or
+// generated from repository, props and current version. Results are sorted
+// server side from newest to oldest so that limit keeps the latest files.
+func (c Check) aqlSpec(filter *utils.Filter) (*spec.SpecFiles, error) {
+	itemsFind, err := parseAqlQuery(c.source.Aql.Query)
+	if err != nil {
+		return nil, err
+	}
+	if itemsFind == "" {
+		content, err := json.Marshal(c.aqlCriteria(filter))
+		if err != nil {
+			return nil, err
+		}
+		itemsFind = string(content)
+	}
+
+	sortBy := "created"
+	if field, _, ok := filter.TimeKey(c.version.Version); ok {
+		sortBy = field
+	}
+
+	specFiles := spec.NewBuilder().
+		SortBy([]string{sortBy}).
+		SortOrder("desc").
+		Limit(c.source.Aql.Limit).
+		BuildSpec()
+	specFiles.Files[0].Aql = servicesutils.Aql{ItemsFind: itemsFind}
+	return specFiles, nil
+}
+
+// parseAqlQuery returns the criteria of given query, either given as a bare
+// criteria object or as items.find(<criteria>). Other domain query parts
+// (include, sort, limit...) are refused as the search spec sets them.
+func parseAqlQuery(query string) (string, error) {
+	criteria := strings.TrimSpace(query)
+	if criteria == "" {
+		return "", nil
+	}
+	if rest, ok := strings.CutPrefix(criteria, "items.find("); ok {
+		inner, ok := strings.CutSuffix(strings.TrimSpace(rest), ")")
+		if !ok {
+			return "", fmt.Errorf("invalid aql.query '%s', must be a criteria object or items.find(<criteria>)", query)
+		}
+		criteria = strings.TrimSpace(inner)
+	}
+	var obj map[string]interface{}
+	if err := json.Unmarshal([]byte(criteria), &obj); err != nil {
+		return "", fmt.Errorf("invalid aql.query '%s', must be a criteria object or items.find(<criteria>) without include, sort or limit: %s", query, err)
+	}
+	return criteria, nil
+}
+
+func (c Check) aqlCriteria(filter *utils.Filter) aqlClause {
+	repository := strings.Trim(c.source.Repository, "/")
+	repo, path, _ := strings.Cut(repository, "/")
+
+	clauses := []aqlClause{
+		{"repo": repo},
+		{"type": "file"},
+	}
+	switch {
+	case path != "" && c.source.Recursive:
+		clauses = append(clauses, aqlClause{"$or": []aqlClause{
+			{"path": aqlClause{"$eq": path}},
+			{"path": aqlClause{"$match": path + "/*"}},
+		}})
+	case path != "":
+		clauses = append(clauses, aqlClause{"path": aqlClause{"$eq": path}})
+	case !c.source.Recursive:
+		clauses = append(clauses, aqlClause{"path": aqlClause{"$eq": "."}})
+	}
+	if c.source.Aql.Name != "" {
+		clauses = append(clauses, aqlClause{"name": aqlClause{"$match": c.source.Aql.Name}})
+	}
+	for key, vals := range c.source.Props {
+		values := []aqlClause{}
+		for _, val := range vals {
+			values = append(values, aqlClause{"@" + key: aqlClause{"$eq": val}})
+		}
+		clauses = append(clauses, aqlClause{"$or": values})
+	}
+	if c.version.Version != "" {
+		if field, value, ok := filter.TimeKey(c.version.Version); ok {
+			clauses = append(clauses, aqlClause{field: aqlClause{"$gte": value}})
+		}
+	}
+	return aqlClause{"$and": clauses}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestParseAqlQuery(t *testing.T) {
+	criteria := `{"repo": "bosh_release", "name": {"$match": "credhub-*.tgz"}}`
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+		valid    bool
+	}{
+		{"empty", "", "", true},
+		{"criteria", criteria, criteria, true},
+		{"items.find", "items.find(" + criteria + ")", criteria, true},
+		{"spaces", "  items.find( " + criteria + " )\n", criteria, true},
+		{"include", "items.find(" + criteria + `).include("name")`, "", false},
+		{"sort", "items.find(" + criteria + `).sort({"$desc": ["created"]})`, "", false},
+		{"unterminated", "items.find(" + criteria, "", false},
+		{"not an object", `["repo"]`, "", false},
+		{"other domain", "builds.find(" + criteria + ")", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := parseAqlQuery(tt.query)
+			if !tt.valid {
+				if err == nil {
+					t.Errorf("expected query '%s' to be refused, got '%s'", tt.query, res)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res != tt.expected {
+				t.Errorf("expected criteria '%s', got '%s'", tt.expected, res)
+			}
+		})
+	}
+}
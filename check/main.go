@@ -2,11 +2,14 @@ package main
 
 import (
 	"os"
+	"path"
+	"regexp"
+	"slices"
 	"sort"
+	"strings"
 
-	"github.com/jfrog/jfrog-cli-artifactory/artifactory/commands/generic"
+	"github.com/Masterminds/semver"
 	artutils "github.com/jfrog/jfrog-cli-core/v2/artifactory/utils"
-	"github.com/jfrog/jfrog-cli-core/v2/common/spec"
 	"github.com/jfrog/jfrog-cli-core/v2/utils/config"
 	"github.com/orange-cloudfoundry/artifactory-resource/model"
 	"github.com/orange-cloudfoundry/artifactory-resource/utils"
@@ -50,72 +53,118 @@ func (c *Check) Run() {
 
 	c.source.Repository = utils.AddTrailingSlashIfNeeded(c.source.Repository)
 
-	builder := spec.NewBuilder()
-	specFiles := builder.
-		Pattern(c.source.Repository).
-		Props(c.source.Props.String()).
-		BuildSpec()
+	specFiles := utils.RepositorySpec(c.source)
+	if c.source.FolderVersions {
+		specFiles = utils.FolderSpec(c.source)
+	}
+	if c.source.Aql.Active() {
+		specFiles, err = c.aqlSpec(utils.NewSourceFilter(c.source))
+		if err != nil {
+			utils.Fatal("error when building aql query: %s", err)
+		}
+	}
 
 	origStdout := os.Stdout
 	os.Stdout = os.Stderr
-	results, err := c.search(specFiles)
+	results, err := utils.Search(c.artdetails, specFiles)
 	os.Stdout = origStdout
 	if err != nil {
 		utils.Fatal("error when trying to find latest file: %s", err)
 	}
 
-	matches := c.filter(results)
+	matches, err := c.filter(results)
+	if err != nil {
+		utils.Fatal("error when filtering files: %s", err)
+	}
 
 	versions := []model.Version{}
+	groups := []map[string]string{}
 	for _, m := range matches {
+		if c.source.GroupFiles {
+			// files of a group share the same version, emit it once
+			if len(versions) == 0 || versions[len(versions)-1].Version != m.key {
+				versions = append(versions, model.Version{Version: m.key})
+				groups = append(groups, map[string]string{})
+			}
+			groups[len(groups)-1][path.Base(m.Path)] = m.Sha256
+			continue
+		}
 		versions = append(versions, model.Version{
 			Version: m.key,
 			File:    m.Path,
+			Sha256:  m.Sha256,
 		})
 	}
+	for i, group := range groups {
+		versions[i].Sha256 = utils.GroupChecksum(group)
+	}
 	if err = utils.SendJsonResponse(versions); err != nil {
 		utils.Log(err.Error())
 	}
 }
 
-func (c Check) search(spec *spec.SpecFiles) ([]artutils.SearchResult, error) {
-	res := []artutils.SearchResult{}
-	cmd := generic.NewSearchCommand()
-	cmd.
-		SetServerDetails(c.artdetails).
-		SetSpec(spec)
+// wildcardToRegexp converts an artifactory wildcard pattern, where '*' and '?'
+// may match '/', to a regexp matching the full path
+func wildcardToRegexp(pattern string) *regexp.Regexp {
+	expr := regexp.QuoteMeta(utils.RemoveStartingSlashIfNeeded(pattern))
+	expr = strings.ReplaceAll(expr, `\*`, ".*")
+	expr = strings.ReplaceAll(expr, `\?`, ".")
+	return regexp.MustCompile("^" + expr + "$")
+}
 
-	err := cmd.Run()
-	if err != nil {
-		return nil, err
-	}
+func (c Check) filter(results []artutils.SearchResult) ([]Match, error) {
+	filter := utils.NewSourceFilter(c.source)
 
-	reader := cmd.Result().Reader()
-	defer utils.CloseAndLogError(reader)
-	_, err = reader.Length()
-	if err != nil {
-		return nil, err
+	var constraint *semver.Constraints
+	if c.source.Constraint != "" {
+		// already validated by CheckReqParams
+		constraint, _ = utils.NewVersionConstraint(c.source.Constraint)
 	}
 
-	for val := new(artutils.SearchResult); reader.NextRecord(val) == nil; val = new(artutils.SearchResult) {
-		res = append(res, *val)
+	// exclusions can't be given to artifactory along with aql
+	exclusions := []*regexp.Regexp{}
+	if c.source.Aql.Active() {
+		for _, pattern := range c.source.Exclusions {
+			exclusions = append(exclusions, wildcardToRegexp(pattern))
+		}
 	}
 
-	return res, nil
-}
-
-func (c Check) filter(results []artutils.SearchResult) []Match {
-	filter := utils.NewFilter(c.source.Filter)
-
 	res := []Match{}
 	for _, file := range results {
-		match, key := filter.Match(file.Path, file.Modified)
+		if slices.ContainsFunc(exclusions, func(re *regexp.Regexp) bool { return re.MatchString(file.Path) }) {
+			continue
+		}
+		if utils.InStaging(c.source, file.Path) {
+			continue
+		}
+		name := utils.FilterPath(c.source, file.Path)
+		if c.source.FolderVersions {
+			// folders are matched on their name, files found along being ignored
+			if file.Type != "folder" {
+				continue
+			}
+			name = path.Base(file.Path)
+		}
+		match, key := filter.Match(name, file.Created, file.Modified)
 		if !match {
 			continue
 		}
+		accept, err := filter.Accept(file.Path, key)
+		if err != nil {
+			return nil, err
+		}
+		if !accept {
+			continue
+		}
 		if c.version.Version != "" && filter.Less(key, c.version.Version) {
 			continue
 		}
+		if constraint != nil {
+			sv, err := semver.NewVersion(filter.Semver(key))
+			if err != nil || !constraint.Check(sv) {
+				continue
+			}
+		}
 		res = append(res, Match{
 			SearchResult: file,
 			key:          key,
@@ -127,5 +176,5 @@ func (c Check) filter(results []artutils.SearchResult) []Match {
 		return filter.Less(res[i].key, res[j].key)
 	})
 
-	return res
+	return res, nil
 }
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"path"
+	"path/filepath"
+	"strings"
+
+	artutils "github.com/jfrog/jfrog-cli-core/v2/artifactory/utils"
+	"github.com/orange-cloudfoundry/artifactory-resource/model"
+	"github.com/orange-cloudfoundry/artifactory-resource/utils"
+)
+
+// Checksums of a file indexed by algorithm
+type Checksums map[string]string
+
+var hashers = map[string]func() hash.Hash{
+	"sha256": sha256.New,
+	"sha1":   sha1.New,
+	"md5":    md5.New,
+}
+
+// algorithms in the order they are reported
+var algorithms = []string{"sha256", "sha1", "md5"}
+
+// localChecksums computes checksums of downloaded files, indexed by remote path
+func (c In) localChecksums(files []string, dest string) (map[string]Checksums, error) {
+	res := map[string]Checksums{}
+	for _, file := range files {
+		res[file] = Checksums{}
+		for _, algo := range algorithms {
+			sum, err := utils.HashFile(filepath.Join(dest, c.localName(file)), hashers[algo]())
+			if err != nil {
+				return nil, fmt.Errorf("unable to compute %s of '%s': %s", algo, file, err)
+			}
+			res[file][algo] = sum
+		}
+	}
+	return res, nil
+}
+
+// checkPinnedChecksum ensures downloaded files match the sha256 pinned in
+// version, detecting files re-uploaded with a different content
+func (c In) checkPinnedChecksum(files []string, checksums map[string]Checksums) error {
+	actual := checksums[files[0]]["sha256"]
+	if c.source.GroupFiles {
+		group := map[string]string{}
+		for _, file := range files {
+			group[path.Base(file)] = checksums[file]["sha256"]
+		}
+		actual = utils.GroupChecksum(group)
+	}
+	if actual != c.version.Sha256 {
+		return fmt.Errorf("sha256 mismatch for version '%s': expected '%s', got '%s'", c.version.Version, c.version.Sha256, actual)
+	}
+	return nil
+}
+
+// remoteChecksums returns checksums stored by artifactory for given file
+func remoteChecksums(remote artutils.SearchResult) Checksums {
+	return Checksums{
+		"sha256": remote.Sha256,
+		"sha1":   remote.Sha1,
+		"md5":    remote.Md5,
+	}
+}
+
+// verifyChecksums compares checksums of downloaded files against the ones
+// stored by artifactory
+func verifyChecksums(files []string, checksums map[string]Checksums, remotes map[string]artutils.SearchResult) error {
+	diffs := []string{}
+	for _, file := range files {
+		remote := remoteChecksums(remotes[file])
+		verified := 0
+		for _, algo := range algorithms {
+			if remote[algo] == "" {
+				continue
+			}
+			verified++
+			if remote[algo] != checksums[file][algo] {
+				diffs = append(diffs, fmt.Sprintf("  %s: %s expected '%s', got '%s'", file, algo, remote[algo], checksums[file][algo]))
+			}
+		}
+		if verified == 0 {
+			return fmt.Errorf("artifactory does not hold any checksum for '%s', set verify_checksums to false to skip verification", file)
+		}
+	}
+	if len(diffs) != 0 {
+		return fmt.Errorf("checksum mismatch of downloaded files:\n%s", strings.Join(diffs, "\n"))
+	}
+	return nil
+}
+
+func (c In) checksumsToMeta(files []string, checksums map[string]Checksums) []model.Metadata {
+	metadata := []model.Metadata{}
+	for _, file := range files {
+		for _, algo := range algorithms {
+			name := algo
+			// prefix with file name to distinguish files of a group
+			if len(files) > 1 {
+				name = c.localName(file) + " " + algo
+			}
+			metadata = append(metadata, model.Metadata{
+				Name:  name,
+				Value: checksums[file][algo],
+			})
+		}
+	}
+	return metadata
+}
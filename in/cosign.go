@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/orange-cloudfoundry/artifactory-resource/model"
+	"github.com/orange-cloudfoundry/artifactory-resource/utils"
+)
+
+// setupCosign validates cosign params and registers signatures as required
+// sidecars, and bundles as optional ones, so that they are downloaded along
+// with files
+func (c *In) setupCosign() error {
+	if !c.params.VerifyCosign {
+		return nil
+	}
+	if c.source.CosignPublicKey == "" {
+		return errors.New("verify_cosign requires source cosign_public_key")
+	}
+	if err := utils.CheckCosignPublicKey(c.source.CosignPublicKey); err != nil {
+		return err
+	}
+	if c.params.SkipDownload {
+		return errors.New("verify_cosign can't be used along with skip_download")
+	}
+	if c.params.CosignSuffix == "" {
+		return errors.New("cosign_signature_suffix must not be empty")
+	}
+	c.addSidecar(c.params.CosignSuffix, true)
+	if c.params.CosignBundleSuffix != "" {
+		c.addSidecar(c.params.CosignBundleSuffix, false)
+	}
+	return nil
+}
+
+// verifyCosign checks cosign signatures of downloaded files, and the
+// signature of their bundle when found, against source public key
+func (c In) verifyCosign(files []string, dest string) ([]model.Metadata, error) {
+	meta := []model.Metadata{}
+	for _, file := range files {
+		name := path.Base(file)
+		local := filepath.Join(dest, name)
+		sig, err := os.ReadFile(local + c.params.CosignSuffix)
+		if err != nil {
+			return nil, fmt.Errorf("cosign verification of '%s' failed: %s", name, err)
+		}
+		if err := utils.CosignVerify(c.source.CosignPublicKey, local, string(sig)); err != nil {
+			return nil, fmt.Errorf("cosign verification of '%s' failed: %s", name, err)
+		}
+		verified := name + c.params.CosignSuffix
+
+		bundle := local + c.params.CosignBundleSuffix
+		if _, err := os.Stat(bundle); c.params.CosignBundleSuffix != "" && err == nil {
+			bundleSig, err := utils.CosignBundleSignature(bundle)
+			if err != nil {
+				return nil, fmt.Errorf("cosign verification of '%s' failed: %s", name, err)
+			}
+			if err := utils.CosignVerify(c.source.CosignPublicKey, local, bundleSig); err != nil {
+				return nil, fmt.Errorf("cosign verification of '%s' with bundle failed: %s", name, err)
+			}
+			verified += ", " + name + c.params.CosignBundleSuffix
+		}
+
+		metaName := "cosign verified"
+		if len(files) > 1 {
+			metaName = name + " cosign verified"
+		}
+		meta = append(meta, model.Metadata{
+			Name:  metaName,
+			Value: verified,
+		})
+	}
+	return meta, nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	artutils "github.com/jfrog/jfrog-cli-core/v2/artifactory/utils"
+	"github.com/jfrog/jfrog-cli-core/v2/common/spec"
+	"github.com/orange-cloudfoundry/artifactory-resource/model"
+	"github.com/orange-cloudfoundry/artifactory-resource/utils"
+)
+
+// checkFolder rejects params working on single files only when versions are
+// folders
+func (c In) checkFolder() error {
+	if !c.source.FolderVersions {
+		return nil
+	}
+	switch {
+	case c.params.Unpack:
+		return errors.New("unpack can't be used along with folder_versions")
+	case len(c.params.Sidecars) != 0:
+		return errors.New("sidecars can't be used along with folder_versions")
+	case c.params.VerifyGpg:
+		return errors.New("verify_gpg can't be used along with folder_versions")
+	case c.params.VerifyCosign:
+		return errors.New("verify_cosign can't be used along with folder_versions")
+	}
+	return nil
+}
+
+// localName returns the path of given remote file relative to the
+// destination, keeping the tree of folder versions
+func (c In) localName(file string) string {
+	if c.source.FolderVersions {
+		return strings.TrimPrefix(file, c.folder()+"/")
+	}
+	return path.Base(file)
+}
+
+func (c In) folder() string {
+	return strings.TrimSuffix(c.version.File, "/")
+}
+
+// folderFiles lists all files of the folder version, and their details
+// indexed by path
+func (c In) folderFiles() ([]string, map[string]artutils.SearchResult, error) {
+	results, err := utils.FolderFiles(c.artdetails, c.folder())
+	if err != nil {
+		return nil, nil, fmt.Errorf("error when listing files of folder '%s': %s", c.folder(), err)
+	}
+	files := []string{}
+	remotes := map[string]artutils.SearchResult{}
+	for _, res := range results {
+		files = append(files, res.Path)
+		remotes[res.Path] = res
+	}
+	if len(files) == 0 {
+		return nil, nil, fmt.Errorf("could not find any file in folder '%s'", c.folder())
+	}
+	return files, remotes, nil
+}
+
+// fetchFolder downloads the whole tree of the folder version to dest and
+// verifies checksums of its files
+func (c *In) fetchFolder(files []string, remotes map[string]artutils.SearchResult, dest string) ([]model.Metadata, error) {
+	// the placeholder keeps the path of files relative to the folder
+	c.spec = spec.NewBuilder().
+		Pattern(c.folder() + "/(*)").
+		Target(dest + "{1}").
+		Recursive(true).
+		BuildSpec()
+	utils.Log("downloading folder '%s' to '%s'...", c.folder(), dest)
+
+	startDl := time.Now()
+
+	origStdout := os.Stdout
+	os.Stdout = os.Stderr
+	err := c.download()
+	os.Stdout = origStdout
+	if err != nil {
+		return nil, fmt.Errorf("error when downloading: %s", err)
+	}
+
+	elapsed := time.Since(startDl)
+	utils.Log("finished downloading folder '%s' to '%s'", c.folder(), dest)
+
+	if c.params.VerifyChecksums {
+		checksums, err := c.localChecksums(files, dest)
+		if err != nil {
+			return nil, err
+		}
+		if err := verifyChecksums(files, checksums, remotes); err != nil {
+			return nil, err
+		}
+	}
+	meta := []model.Metadata{
+		{Name: "files", Value: strconv.Itoa(len(files))},
+		{Name: "elapsed", Value: elapsed.String()},
+	}
+	return meta, nil
+}
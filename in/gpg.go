@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/orange-cloudfoundry/artifactory-resource/model"
+	"github.com/orange-cloudfoundry/artifactory-resource/utils"
+)
+
+// setupGpg validates gpg params and registers detached signatures as
+// required sidecars so that they are downloaded along with files
+func (c *In) setupGpg() error {
+	if !c.params.VerifyGpg {
+		return nil
+	}
+	if len(c.source.GpgPublicKeys) == 0 {
+		return errors.New("verify_gpg requires source gpg_public_keys")
+	}
+	if c.params.SkipDownload {
+		return errors.New("verify_gpg can't be used along with skip_download")
+	}
+	if c.params.GpgSuffix == "" {
+		return errors.New("gpg_signature_suffix must not be empty")
+	}
+	c.addSidecar(c.params.GpgSuffix, true)
+	return nil
+}
+
+func (c In) gpgKeyring() (openpgp.EntityList, error) {
+	keyring := openpgp.EntityList{}
+	for i, key := range c.source.GpgPublicKeys {
+		entities, err := openpgp.ReadArmoredKeyRing(strings.NewReader(key))
+		if err != nil {
+			return nil, fmt.Errorf("invalid gpg public key #%d: %s", i+1, err)
+		}
+		keyring = append(keyring, entities...)
+	}
+	return keyring, nil
+}
+
+// verifyGpg checks detached signatures of downloaded files against source
+// public keys and returns the fingerprint of signing keys as metadata
+func (c In) verifyGpg(files []string, dest string) ([]model.Metadata, error) {
+	keyring, err := c.gpgKeyring()
+	if err != nil {
+		return nil, err
+	}
+	meta := []model.Metadata{}
+	for _, file := range files {
+		name := path.Base(file)
+		fingerprint, err := verifyGpgSignature(keyring, filepath.Join(dest, name), filepath.Join(dest, name+c.params.GpgSuffix))
+		if err != nil {
+			return nil, fmt.Errorf("gpg verification of '%s' failed: %s", name, err)
+		}
+		metaName := "gpg fingerprint"
+		if len(files) > 1 {
+			metaName = name + " gpg fingerprint"
+		}
+		meta = append(meta, model.Metadata{
+			Name:  metaName,
+			Value: fingerprint,
+		})
+	}
+	return meta, nil
+}
+
+// verifyGpgSignature verifies given armored or binary detached signature of
+// file and returns the fingerprint of the signing key
+func verifyGpgSignature(keyring openpgp.EntityList, file string, signature string) (string, error) {
+	sig, err := os.ReadFile(signature)
+	if err != nil {
+		return "", err
+	}
+	signed, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer utils.CloseAndLogError(signed)
+
+	check := openpgp.CheckDetachedSignature
+	if bytes.HasPrefix(bytes.TrimSpace(sig), []byte("-----BEGIN")) {
+		check = openpgp.CheckArmoredDetachedSignature
+	}
+	signer, err := check(keyring, signed, bytes.NewReader(sig), nil)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%X", signer.PrimaryKey.Fingerprint), nil
+}
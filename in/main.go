@@ -1,9 +1,13 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	buildutils "github.com/jfrog/jfrog-cli-core/v2/common/build"
@@ -14,7 +18,6 @@ import (
 	"github.com/jfrog/jfrog-cli-core/v2/utils/config"
 	"github.com/orange-cloudfoundry/artifactory-resource/model"
 	"github.com/orange-cloudfoundry/artifactory-resource/utils"
-	"gopkg.in/yaml.v3"
 )
 
 type In struct {
@@ -47,6 +50,25 @@ func (c *In) Run() {
 		utils.Fatal(err.Error())
 	}
 
+	if err := c.checkUnpack(); err != nil {
+		utils.Fatal(err.Error())
+	}
+	if err := checkPropsFormat(c.params.PropsFormat); err != nil {
+		utils.Fatal(err.Error())
+	}
+	if err := c.checkSidecars(); err != nil {
+		utils.Fatal(err.Error())
+	}
+	if err := c.setupGpg(); err != nil {
+		utils.Fatal(err.Error())
+	}
+	if err := c.setupCosign(); err != nil {
+		utils.Fatal(err.Error())
+	}
+	if err := c.checkFolder(); err != nil {
+		utils.Fatal(err.Error())
+	}
+
 	c.artdetails, err = utils.RetrieveArtDetails(c.source)
 	if err != nil {
 		utils.Fatal(err.Error())
@@ -54,49 +76,254 @@ func (c *In) Run() {
 
 	c.source.Repository = utils.AddTrailingSlashIfNeeded(c.source.Repository)
 	dest := utils.AddTrailingSlashIfNeeded(filepath.Join(utils.BaseDirectory(), c.params.Destination))
-	builder := spec.NewBuilder()
-	c.spec = builder.
-		Pattern(c.version.File).
-		Target(dest).
-		Flat(true).
-		Props(c.source.Props.String()).
-		BuildSpec()
+	files := []string{c.version.File}
+	if c.source.GroupFiles {
+		files, err = utils.GroupFiles(c.artdetails, c.source, c.version.Version)
+		if err != nil {
+			utils.Fatal("error when listing files of version '%s': %s", c.version.Version, err)
+		}
+		if len(files) == 0 {
+			utils.Fatal("could not find any file for version '%s'", c.version.Version)
+		}
+	}
+	var remotes map[string]artutils.SearchResult
+	if c.source.FolderVersions {
+		files, remotes, err = c.folderFiles()
+	} else {
+		remotes, err = c.remoteFiles(files)
+	}
+	if err != nil {
+		utils.Fatal(err.Error())
+	}
+
+	sidecars, err := c.sidecars(files)
+	if err != nil {
+		utils.Fatal(err.Error())
+	}
+
+	var meta []model.Metadata
+	switch {
+	case c.params.SkipDownload:
+		utils.Log("skipping download of '%s'", strings.Join(slices.Concat(files, sidecars), "', '"))
+		meta, err = c.describe(files, remotes)
+	case c.source.FolderVersions:
+		meta, err = c.fetchFolder(files, remotes, dest)
+	default:
+		meta, err = c.fetch(files, sidecars, remotes, dest)
+	}
+	if err != nil {
+		utils.Fatal(err.Error())
+	}
+	if len(sidecars) != 0 {
+		meta = append(meta, sidecarsToMeta(sidecars))
+	}
+
+	if err := c.writeInfoFiles(files, remotes, dest); err != nil {
+		utils.Fatal("error when writing version files: %s", err)
+	}
+
+	if c.params.PropsFilename != "" {
+		// properties of grouped versions are read from their first file
+		propsFile := files[0]
+		if c.source.FolderVersions {
+			propsFile = c.folder()
+		}
+		utils.Log("downloading properties for '%s' to '%s'...", propsFile, c.params.PropsFilename)
+		val := c.downloadProps(propsFile, c.params.PropsFilename)
+		utils.Log("finished downloading properties for '%s' to '%s'", propsFile, c.params.PropsFilename)
+		utils.Log("%s", val)
+	}
+
+	err = utils.SendJsonResponse(model.Response{
+		Metadata: meta,
+		Version:  c.version,
+	})
+	if err != nil {
+		utils.Log(err.Error())
+	}
+}
+
+// fetch downloads given files along with their sidecars to dest and verifies
+// their checksums
+func (c *In) fetch(files []string, sidecars []string, remotes map[string]artutils.SearchResult, dest string) ([]model.Metadata, error) {
+	c.spec = &spec.SpecFiles{
+		Files: []spec.File{},
+	}
+	for _, file := range slices.Concat(files, sidecars) {
+		// sidecars are found without props filter, they must be downloaded
+		// the same way
+		props := model.Properties{}
+		if slices.Contains(files, file) {
+			props = c.source.Props
+		}
+		builder := spec.NewBuilder()
+		buildSpec := builder.
+			Pattern(file).
+			Target(dest).
+			Flat(true).
+			Props(props.String()).
+			BuildSpec()
+		c.spec.Files = append(c.spec.Files, buildSpec.Files...)
+		utils.Log("downloading '%s' to '%s'...", file, dest)
+	}
 
-	utils.Log("downloading '%s' to '%s'...", c.version.File, dest)
 	startDl := time.Now()
 
 	origStdout := os.Stdout
 	os.Stdout = os.Stderr
-	meta, err := c.download()
+	err := c.download()
 	os.Stdout = origStdout
 	if err != nil {
-		utils.Fatal("error when downloading: %s", err)
+		return nil, fmt.Errorf("error when downloading: %s", err)
 	}
 
 	elapsed := time.Since(startDl)
-	utils.Log("finished downloading '%s' to '%s'", c.version.File, dest)
+	utils.Log("finished downloading '%s' to '%s'", strings.Join(slices.Concat(files, sidecars), "', '"), dest)
+
+	checksums, err := c.localChecksums(files, dest)
+	if err != nil {
+		return nil, err
+	}
+	if c.version.Sha256 != "" {
+		if err := c.checkPinnedChecksum(files, checksums); err != nil {
+			return nil, err
+		}
+	}
+	if c.params.VerifyChecksums {
+		if err := verifyChecksums(files, checksums, remotes); err != nil {
+			return nil, err
+		}
+	}
+	meta := c.checksumsToMeta(files, checksums)
+	if c.params.VerifyGpg {
+		signers, err := c.verifyGpg(files, dest)
+		if err != nil {
+			return nil, err
+		}
+		meta = append(meta, signers...)
+	}
+	if c.params.VerifyCosign {
+		verified, err := c.verifyCosign(files, dest)
+		if err != nil {
+			return nil, err
+		}
+		meta = append(meta, verified...)
+	}
+	if c.params.Unpack {
+		unpacked, err := c.unpack(files, dest)
+		if err != nil {
+			return nil, err
+		}
+		meta = append(meta, unpacked...)
+	}
 	meta = append(meta, model.Metadata{
 		Name:  "elapsed",
 		Value: elapsed.String(),
 	})
+	return meta, nil
+}
 
-	if c.params.PropsFilename != "" {
-		utils.Log("downloading properties for '%s' to '%s'...", c.version.File, c.params.PropsFilename)
-		val := c.downloadProps(c.version.File, c.params.PropsFilename)
-		utils.Log("finished downloading properties for '%s' to '%s'", c.version.File, c.params.PropsFilename)
-		utils.Log("%s", val)
+// describe returns metadata of given files as stored by artifactory,
+// without downloading them
+func (c In) describe(files []string, remotes map[string]artutils.SearchResult) ([]model.Metadata, error) {
+	checksums := map[string]Checksums{}
+	for _, file := range files {
+		checksums[file] = remoteChecksums(remotes[file])
 	}
+	if c.version.Sha256 != "" {
+		if err := c.checkPinnedChecksum(files, checksums); err != nil {
+			return nil, err
+		}
+	}
+	meta := c.checksumsToMeta(files, checksums)
+	for _, file := range files {
+		prefix := ""
+		if len(files) > 1 {
+			prefix = c.localName(file) + " "
+		}
+		meta = append(meta,
+			model.Metadata{Name: prefix + "size", Value: strconv.FormatInt(remotes[file].Size, 10)},
+			model.Metadata{Name: prefix + "created", Value: remotes[file].Created},
+			model.Metadata{Name: prefix + "modified", Value: remotes[file].Modified},
+		)
+	}
+	return meta, nil
+}
 
-	err = utils.SendJsonResponse(model.Response{
-		Metadata: meta,
-		Version:  c.version,
-	})
+// remoteFiles fetches details stored by artifactory of given files, indexed
+// by path
+func (c In) remoteFiles(files []string) (map[string]artutils.SearchResult, error) {
+	res := map[string]artutils.SearchResult{}
+	for _, file := range files {
+		spc := spec.NewBuilder().
+			Pattern(file).
+			BuildSpec()
+		results, err := utils.Search(c.artdetails, spc)
+		if err != nil {
+			return nil, fmt.Errorf("unable to fetch details of '%s': %s", file, err)
+		}
+		if len(results) != 1 {
+			return nil, fmt.Errorf("unable to fetch details of '%s': found %d files", file, len(results))
+		}
+		res[file] = results[0]
+	}
+	return res, nil
+}
+
+// writeInfoFiles writes version, filename, url, sha256 and metadata.json files
+// describing fetched files in a dedicated directory of dest, one line per file
+// for grouped versions
+func (c In) writeInfoFiles(files []string, remotes map[string]artutils.SearchResult, dest string) error {
+	infoDir := filepath.Join(dest, utils.INFO_DIR)
+	// never overwrite downloaded or extracted files
+	if _, err := os.Lstat(infoDir); err == nil {
+		return fmt.Errorf("'%s' already exists in destination, refusing to overwrite it", utils.INFO_DIR)
+	}
+	if err := os.MkdirAll(infoDir, os.ModePerm); err != nil {
+		return err
+	}
+	names := []string{}
+	urls := []string{}
+	sums := []string{}
+	details := []artutils.SearchResult{}
+	for _, file := range files {
+		names = append(names, c.localName(file))
+		urls = append(urls, utils.AddTrailingSlashIfNeeded(c.source.Url)+utils.RemoveStartingSlashIfNeeded(file))
+		details = append(details, remotes[file])
+		if len(files) > 1 {
+			sums = append(sums, fmt.Sprintf("%s  %s", remotes[file].Sha256, c.localName(file)))
+		} else {
+			sums = append(sums, remotes[file].Sha256)
+		}
+	}
+
+	var metadata interface{} = details
+	if !c.source.GroupFiles && !c.source.FolderVersions {
+		metadata = details[0]
+	}
+	content, err := json.MarshalIndent(metadata, "", "  ")
 	if err != nil {
-		utils.Log(err.Error())
+		return err
+	}
+
+	infos := map[string]string{
+		"version":       c.version.Version,
+		"filename":      strings.Join(names, "\n"),
+		"url":           strings.Join(urls, "\n"),
+		"sha256":        strings.Join(sums, "\n"),
+		"metadata.json": string(content),
 	}
+	for name, val := range infos {
+		if err := os.WriteFile(filepath.Join(infoDir, name), []byte(val+"\n"), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func (c In) download() ([]model.Metadata, error) {
+// download runs the download spec, checksums of downloaded files being
+// reported by callers
+func (c In) download() error {
 	cmd := generic.NewDownloadCommand()
 	cmd.SetConfiguration(&artutils.DownloadConfiguration{
 		Threads:      c.source.Threads,
@@ -106,15 +333,9 @@ func (c In) download() ([]model.Metadata, error) {
 
 	cmd.
 		SetServerDetails(c.artdetails).
-		SetDetailedSummary(true).
 		SetSpec(c.spec)
 
-	err := cmd.Run()
-	if err != nil {
-		return nil, err
-	}
-
-	return utils.TransfertDetailsToMeta(cmd.Result()), nil
+	return cmd.Run()
 }
 
 func (c In) downloadProps(remoteFile string, propsFilename string) string {
@@ -122,6 +343,7 @@ func (c In) downloadProps(remoteFile string, propsFilename string) string {
 	spc := builder.
 		Pattern(remoteFile).
 		Props(model.Properties{}.String()).
+		IncludeDirs(true).
 		BuildSpec()
 
 	cmd := generic.NewSearchCommand()
@@ -131,22 +353,25 @@ func (c In) downloadProps(remoteFile string, propsFilename string) string {
 
 	err := cmd.Run()
 	if err != nil {
-		utils.Fatal(fmt.Sprintf("unable to fetch properties for file '%s': %s", c.version.File, err))
+		utils.Fatal(fmt.Sprintf("unable to fetch properties for file '%s': %s", remoteFile, err))
 	}
 
 	reader := cmd.Result().Reader()
 	defer utils.CloseAndLogError(reader)
 	_, err = reader.Length()
 	if err != nil {
-		utils.Fatal(fmt.Sprintf("error while reading properties for file '%s': %s", c.version.File, err))
+		utils.Fatal(fmt.Sprintf("error while reading properties for file '%s': %s", remoteFile, err))
 	}
 
 	if length, _ := reader.Length(); length != 1 {
-		utils.Fatal(fmt.Sprintf("error: found more than one property set for '%s'", c.version.File))
+		utils.Fatal(fmt.Sprintf("error: found more than one property set for '%s'", remoteFile))
 	}
 
 	for res := new(artutils.SearchResult); reader.NextRecord(res) == nil; {
-		content, _ := yaml.Marshal(res.Props)
+		content, err := formatProps(model.Properties(res.Props), c.params.PropsFormat)
+		if err != nil {
+			utils.Fatal(fmt.Sprintf("unable to format properties for file '%s': %s", remoteFile, err))
+		}
 		path := filepath.Join(utils.BaseDirectory(), propsFilename)
 		err = os.WriteFile(path, content, 0644)
 		if err != nil {
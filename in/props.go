@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+
+	"github.com/orange-cloudfoundry/artifactory-resource/model"
+	"gopkg.in/yaml.v3"
+)
+
+// multi-valued properties are written as a single value joined by
+// PROPS_VALUES_SEPARATOR in flat formats
+const PROPS_VALUES_SEPARATOR = ","
+
+var propsFormats = []string{"yaml", "json", "env", "properties"}
+
+var envNameRe = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+func checkPropsFormat(format string) error {
+	if !slices.Contains(propsFormats, format) {
+		return fmt.Errorf("invalid props_format '%s', must be one of %s", format, strings.Join(propsFormats, ", "))
+	}
+	return nil
+}
+
+// formatProps renders given properties in given format. Structured formats
+// keep all values as lists while flat ones join them.
+func formatProps(props model.Properties, format string) ([]byte, error) {
+	switch format {
+	case "json":
+		return json.MarshalIndent(props, "", "  ")
+	case "env":
+		return formatFlatProps(props, envName, shellQuote)
+	case "properties":
+		return formatFlatProps(props, javaEscapeKey, javaEscapeValue)
+	}
+	return yaml.Marshal(props)
+}
+
+// formatFlatProps writes one line per property, refusing properties whose
+// names are written the same way as a later line would override the first
+func formatFlatProps(props model.Properties, key func(string) string, value func(string) string) ([]byte, error) {
+	names := make([]string, 0, len(props))
+	for name := range props {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	sb := strings.Builder{}
+	keys := map[string]string{}
+	for _, name := range names {
+		k := key(name)
+		if other, ok := keys[k]; ok {
+			return nil, fmt.Errorf("properties '%s' and '%s' are both written as '%s', use yaml or json props_format instead", other, name, k)
+		}
+		keys[k] = name
+		sb.WriteString(k)
+		sb.WriteString("=")
+		sb.WriteString(value(strings.Join(props[name], PROPS_VALUES_SEPARATOR)))
+		sb.WriteString("\n")
+	}
+	return []byte(sb.String()), nil
+}
+
+// envName turns given property name into a valid shell variable name
+func envName(name string) string {
+	name = envNameRe.ReplaceAllString(name, "_")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "_" + name
+	}
+	return name
+}
+
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+var javaValueEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"\n", `\n`,
+	"\r", `\r`,
+	"\t", `\t`,
+)
+
+var javaKeyEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"\n", `\n`,
+	"\r", `\r`,
+	"\t", `\t`,
+	" ", `\ `,
+	"=", `\=`,
+	":", `\:`,
+	"#", `\#`,
+	"!", `\!`,
+)
+
+func javaEscapeKey(key string) string {
+	return javaEscapeUnicode(javaKeyEscaper.Replace(key))
+}
+
+// javaEscapeValue escapes given value, leading spaces being escaped as well
+// to be kept when loaded
+func javaEscapeValue(value string) string {
+	value = javaEscapeUnicode(javaValueEscaper.Replace(value))
+	trimmed := strings.TrimLeft(value, " ")
+	return strings.Repeat(`\ `, len(value)-len(trimmed)) + trimmed
+}
+
+// javaEscapeUnicode escapes non ASCII characters as \uXXXX, UTF-16 surrogate
+// pairs for characters beyond the BMP, as properties files are read as
+// ISO-8859-1 by Java
+func javaEscapeUnicode(value string) string {
+	sb := strings.Builder{}
+	for _, r := range value {
+		if r <= 0x7e {
+			sb.WriteRune(r)
+			continue
+		}
+		if r1, r2 := utf16.EncodeRune(r); r1 != unicode.ReplacementChar {
+			fmt.Fprintf(&sb, `\u%04X\u%04X`, r1, r2)
+			continue
+		}
+		fmt.Fprintf(&sb, `\u%04X`, r)
+	}
+	return sb.String()
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/orange-cloudfoundry/artifactory-resource/model"
+)
+
+func TestEnvName(t *testing.T) {
+	tests := map[string]string{
+		"build_name": "build_name",
+		"build.name": "build_name",
+		"qa-status":  "qa_status",
+		"1st":        "_1st",
+		"a b=c$d":    "a_b_c_d",
+		"":           "_",
+	}
+	for name, expected := range tests {
+		if res := envName(name); res != expected {
+			t.Errorf("expected env name '%s' for '%s', got '%s'", expected, name, res)
+		}
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	tests := map[string]string{
+		"value":      `'value'`,
+		"it's":       `'it'\''s'`,
+		"$HOME `id`": "'$HOME `id`'",
+		"":           `''`,
+	}
+	for value, expected := range tests {
+		if res := shellQuote(value); res != expected {
+			t.Errorf("expected quoted '%s' for '%s', got '%s'", expected, value, res)
+		}
+	}
+}
+
+func TestJavaEscape(t *testing.T) {
+	values := map[string]string{
+		"value":       "value",
+		`c:\dir`:      `c:\\dir`,
+		"two\nlines":  `two\nlines`,
+		"  lead":      `\ \ lead`,
+		"trail  ":     "trail  ",
+		"a=b:c #d !e": "a=b:c #d !e",
+		"café":        `caf\u00E9`,
+		"tilde~":      "tilde~",
+		"\u007f":      `\u007F`,
+		"日本":          `\u65E5\u672C`,
+		" €":          `\ \u20AC`,
+		"🎉":           `\uD83C\uDF89`,
+	}
+	for value, expected := range values {
+		if res := javaEscapeValue(value); res != expected {
+			t.Errorf("expected escaped value '%s' for '%s', got '%s'", expected, value, res)
+		}
+	}
+
+	keys := map[string]string{
+		"build.name": "build.name",
+		"a b":        `a\ b`,
+		"a=b:c":      `a\=b\:c`,
+		"#comment":   `\#comment`,
+		"!comment":   `\!comment`,
+		"équipe":     `\u00E9quipe`,
+	}
+	for key, expected := range keys {
+		if res := javaEscapeKey(key); res != expected {
+			t.Errorf("expected escaped key '%s' for '%s', got '%s'", expected, key, res)
+		}
+	}
+}
+
+func TestFormatProps(t *testing.T) {
+	props := model.Properties{
+		"build.name": {"app"},
+		"qa":         {"passed", "it's ok"},
+	}
+	tests := map[string]string{
+		"env":        "build_name='app'\nqa='passed,it'\\''s ok'\n",
+		"properties": "build.name=app\nqa=passed,it's ok\n",
+		"json":       "{\n  \"build.name\": [\n    \"app\"\n  ],\n  \"qa\": [\n    \"passed\",\n    \"it's ok\"\n  ]\n}",
+		"yaml":       "build.name:\n    - app\nqa:\n    - passed\n    - it's ok\n",
+	}
+	for format, expected := range tests {
+		content, err := formatProps(props, format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(content) != expected {
+			t.Errorf("expected %s:\n%s\ngot:\n%s", format, expected, content)
+		}
+	}
+	if err := checkPropsFormat("xml"); err == nil {
+		t.Error("expected props_format 'xml' to be refused")
+	}
+}
+
+func TestFormatPropsCollision(t *testing.T) {
+	props := model.Properties{
+		"build.name": {"app"},
+		"build_name": {"other"},
+	}
+	if _, err := formatProps(props, "env"); err == nil {
+		t.Error("expected env format to refuse properties written as the same variable")
+	}
+	for _, format := range []string{"properties", "json", "yaml"} {
+		if _, err := formatProps(props, format); err != nil {
+			t.Errorf("expected %s format to keep both properties, got: %s", format, err)
+		}
+	}
+	if _, err := formatProps(model.Properties{"a b": {"1"}, "a_b": {"2"}, "a.b": {"3"}}, "env"); err == nil {
+		t.Error("expected env format to refuse any of the properties written as the same variable")
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/jfrog/jfrog-cli-core/v2/common/spec"
+	"github.com/orange-cloudfoundry/artifactory-resource/model"
+	"github.com/orange-cloudfoundry/artifactory-resource/utils"
+)
+
+func (c In) checkSidecars() error {
+	if len(c.params.Sidecars) != 0 && c.params.SkipDownload {
+		return errors.New("sidecars can't be used along with skip_download")
+	}
+	for _, sidecar := range c.params.Sidecars {
+		if sidecar.Suffix == "" {
+			return errors.New("sidecars must be given a suffix")
+		}
+	}
+	return nil
+}
+
+// addSidecar registers a sidecar needed by a verification, making an
+// already configured one required when asked to
+func (c *In) addSidecar(suffix string, required bool) {
+	for i, sidecar := range c.params.Sidecars {
+		if sidecar.Suffix == suffix {
+			c.params.Sidecars[i].Required = c.params.Sidecars[i].Required || required
+			return
+		}
+	}
+	c.params.Sidecars = append(c.params.Sidecars, model.Sidecar{
+		Suffix:   suffix,
+		Required: required,
+	})
+}
+
+// sidecars resolves in artifactory the sidecar files (signatures,
+// checksums...) of given files, failing when a required one is missing
+func (c In) sidecars(files []string) ([]string, error) {
+	res := []string{}
+	for _, file := range files {
+		for _, sidecar := range c.params.Sidecars {
+			remote := file + sidecar.Suffix
+			spc := spec.NewBuilder().
+				Pattern(remote).
+				BuildSpec()
+			results, err := utils.Search(c.artdetails, spc)
+			if err != nil {
+				return nil, fmt.Errorf("unable to find sidecar '%s': %s", remote, err)
+			}
+			if len(results) == 0 {
+				if sidecar.Required {
+					return nil, fmt.Errorf("required sidecar '%s' not found", remote)
+				}
+				utils.Log("skipping optional sidecar '%s': not found", remote)
+				continue
+			}
+			res = append(res, remote)
+		}
+	}
+	return res, nil
+}
+
+func sidecarsToMeta(sidecars []string) model.Metadata {
+	names := []string{}
+	for _, sidecar := range sidecars {
+		names = append(names, path.Base(sidecar))
+	}
+	return model.Metadata{
+		Name:  "sidecars",
+		Value: strings.Join(names, ", "),
+	}
+}
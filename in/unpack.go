@@ -0,0 +1,243 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/jfrog/archiver/v3"
+	"github.com/orange-cloudfoundry/artifactory-resource/model"
+	"github.com/orange-cloudfoundry/artifactory-resource/utils"
+)
+
+// archive formats handled by unpack, indexed by their extensions
+var unpackFormats = []struct {
+	format string
+	exts   []string
+}{
+	{"tgz", []string{".tar.gz", ".tgz"}},
+	{"txz", []string{".tar.xz", ".txz"}},
+	{"tar", []string{".tar"}},
+	{"zip", []string{".zip"}},
+	{"xz", []string{".xz"}},
+}
+
+func (c In) checkUnpack() error {
+	if c.params.Unpack && c.params.SkipDownload {
+		return errors.New("unpack can't be used along with skip_download")
+	}
+	return checkUnpackFormat(c.params.UnpackFormat)
+}
+
+func checkUnpackFormat(format string) error {
+	if format == "" {
+		return nil
+	}
+	for _, f := range unpackFormats {
+		if f.format == format {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid unpack_format '%s', must be one of tar, tgz, txz, zip or xz", format)
+}
+
+// detectFormat returns the archive format of given file from its extension
+func detectFormat(name string) string {
+	for _, f := range unpackFormats {
+		for _, ext := range f.exts {
+			if strings.HasSuffix(name, ext) {
+				return f.format
+			}
+		}
+	}
+	return ""
+}
+
+// unpack extracts downloaded archives in dest, archives of unknown format
+// being skipped for versions made of multiple files
+func (c In) unpack(files []string, dest string) ([]model.Metadata, error) {
+	meta := []model.Metadata{}
+	for _, file := range files {
+		name := path.Base(file)
+		format := c.params.UnpackFormat
+		if format == "" {
+			format = detectFormat(name)
+		}
+		if format == "" {
+			if len(files) > 1 {
+				utils.Log("skipping unpack of '%s': unknown archive format", name)
+				continue
+			}
+			return nil, fmt.Errorf("unable to unpack '%s': unknown archive format, use unpack_format to force it", name)
+		}
+
+		utils.Log("unpacking '%s' as %s to '%s'...", name, format, dest)
+		extracted, err := unpackFile(filepath.Join(dest, name), format, dest)
+		if err != nil {
+			return nil, fmt.Errorf("unable to unpack '%s': %s", name, err)
+		}
+		utils.Log("finished unpacking '%s'", name)
+
+		metaName := "extracted"
+		if len(files) > 1 {
+			metaName = name + " extracted"
+		}
+		meta = append(meta, model.Metadata{
+			Name:  metaName,
+			Value: strings.Join(extracted, ", "),
+		})
+	}
+	return meta, nil
+}
+
+// unpackFile extracts given archive in dest and returns the list of
+// extracted files, refusing archives holding entries outside of dest
+func unpackFile(source string, format string, dest string) ([]string, error) {
+	if format == "xz" {
+		target := strings.TrimSuffix(filepath.Base(source), ".xz")
+		if target == filepath.Base(source) {
+			target += ".out"
+		}
+		fc := archiver.FileCompressor{Decompressor: archiver.NewXz(), OverwriteExisting: true}
+		if err := fc.DecompressFile(source, filepath.Join(dest, target)); err != nil {
+			return nil, err
+		}
+		return []string{target}, nil
+	}
+
+	var unarchiver interface {
+		archiver.Unarchiver
+		archiver.Walker
+	}
+	switch format {
+	case "tar":
+		t := archiver.NewTar()
+		t.OverwriteExisting = true
+		t.MkdirAll = true
+		unarchiver = t
+	case "tgz":
+		t := archiver.NewTarGz()
+		t.OverwriteExisting = true
+		t.MkdirAll = true
+		unarchiver = t
+	case "txz":
+		t := archiver.NewTarXz()
+		t.OverwriteExisting = true
+		t.MkdirAll = true
+		unarchiver = t
+	case "zip":
+		z := archiver.NewZip()
+		z.OverwriteExisting = true
+		z.MkdirAll = true
+		unarchiver = z
+	}
+
+	extracted := []string{}
+	links := map[string]bool{}
+	err := unarchiver.Walk(source, func(f archiver.File) error {
+		name, link, err := entryNames(f)
+		if err != nil {
+			return err
+		}
+		if err := checkEntry(dest, name, link, f.Header, links); err != nil {
+			return err
+		}
+		if link != "" {
+			links[path.Clean(name)] = true
+		}
+		if !f.IsDir() {
+			extracted = append(extracted, path.Clean(name))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := unarchiver.Unarchive(source, dest); err != nil {
+		return nil, err
+	}
+	return extracted, nil
+}
+
+// entryNames returns the path of given archive entry and the target of
+// links, if any. Zip symlinks hold their target as content.
+func entryNames(f archiver.File) (string, string, error) {
+	switch h := f.Header.(type) {
+	case *tar.Header:
+		if h.Typeflag == tar.TypeSymlink || h.Typeflag == tar.TypeLink {
+			return h.Name, h.Linkname, nil
+		}
+		return h.Name, "", nil
+	case zip.FileHeader:
+		if h.FileInfo().Mode()&os.ModeSymlink == 0 {
+			return h.Name, "", nil
+		}
+		link, err := io.ReadAll(f)
+		if err != nil {
+			return "", "", fmt.Errorf("unable to read link '%s' in archive: %s", h.Name, err)
+		}
+		return h.Name, strings.TrimSpace(string(link)), nil
+	}
+	return f.Name(), "", nil
+}
+
+// checkEntry ensures an archive entry, and the target of links, stays
+// inside dest to prevent path traversal. As paths are checked as text,
+// entries and link targets going through links of the archive are refused.
+func checkEntry(dest string, name string, link string, header interface{}, links map[string]bool) error {
+	if filepath.IsAbs(name) || !within(dest, filepath.Join(dest, name)) {
+		return fmt.Errorf("illegal path '%s' in archive, outside of destination", name)
+	}
+	// path.Dir would clean the directory, hiding links followed by '..'
+	dir := "."
+	if i := strings.LastIndex(strings.TrimSuffix(filepath.ToSlash(name), "/"), "/"); i >= 0 {
+		dir = filepath.ToSlash(name)[:i]
+	}
+	if throughLink(links, dir) {
+		return fmt.Errorf("illegal path '%s' in archive, going through a link", name)
+	}
+	if link == "" {
+		return nil
+	}
+	// symlinks are relative to their directory, hard links to the archive root
+	target := filepath.Join(dest, filepath.Dir(name), link)
+	linkPath := dir + "/" + filepath.ToSlash(link)
+	if h, ok := header.(*tar.Header); ok && h.Typeflag == tar.TypeLink {
+		target = filepath.Join(dest, link)
+		linkPath = filepath.ToSlash(link)
+	}
+	if filepath.IsAbs(link) || !within(dest, target) {
+		return fmt.Errorf("illegal link '%s' -> '%s' in archive, outside of destination", name, link)
+	}
+	if throughLink(links, linkPath) {
+		return fmt.Errorf("illegal link '%s' -> '%s' in archive, going through a link", name, link)
+	}
+	return nil
+}
+
+// throughLink tells if given slash separated path, resolved component by
+// component, goes through one of given links
+func throughLink(links map[string]bool, p string) bool {
+	cur := ""
+	for _, part := range strings.Split(p, "/") {
+		cur = path.Join(cur, part)
+		if links[cur] {
+			return true
+		}
+	}
+	return false
+}
+
+func within(parent string, sub string) bool {
+	rel, err := filepath.Rel(parent, sub)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(os.PathSeparator))
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestCheckEntry(t *testing.T) {
+	dest := "/tmp/dest"
+	tests := []struct {
+		name   string
+		entry  string
+		link   string
+		header interface{}
+		valid  bool
+	}{
+		{"file", "bin/app", "", &tar.Header{Typeflag: tar.TypeReg}, true},
+		{"dot slash file", "./bin/app", "", &tar.Header{Typeflag: tar.TypeReg}, true},
+		{"inner parent", "bin/../lib/app.so", "", &tar.Header{Typeflag: tar.TypeReg}, true},
+		{"parent traversal", "../app", "", &tar.Header{Typeflag: tar.TypeReg}, false},
+		{"nested traversal", "bin/../../app", "", &tar.Header{Typeflag: tar.TypeReg}, false},
+		{"sibling prefix", "../dest-other/app", "", &tar.Header{Typeflag: tar.TypeReg}, false},
+		{"absolute path", "/etc/passwd", "", &tar.Header{Typeflag: tar.TypeReg}, false},
+		{"zip traversal", "../app", "", zip.FileHeader{}, false},
+		{"relative symlink", "bin/app", "../lib/app", &tar.Header{Typeflag: tar.TypeSymlink}, true},
+		{"symlink escaping", "bin/app", "../../etc/passwd", &tar.Header{Typeflag: tar.TypeSymlink}, false},
+		{"absolute symlink", "bin/app", "/etc/passwd", &tar.Header{Typeflag: tar.TypeSymlink}, false},
+		{"hard link from root", "bin/app", "lib/app", &tar.Header{Typeflag: tar.TypeLink}, true},
+		{"hard link escaping", "bin/app", "../etc/passwd", &tar.Header{Typeflag: tar.TypeLink}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkEntry(dest, tt.entry, tt.link, tt.header, map[string]bool{})
+			if tt.valid && err != nil {
+				t.Errorf("expected entry '%s' -> '%s' to be valid, got: %s", tt.entry, tt.link, err)
+			}
+			if !tt.valid && err == nil {
+				t.Errorf("expected entry '%s' -> '%s' to be refused", tt.entry, tt.link)
+			}
+		})
+	}
+}
+
+func TestCheckEntryThroughLinks(t *testing.T) {
+	dest := "/tmp/dest"
+	links := map[string]bool{"a": true, "lib/current": true}
+	tests := []struct {
+		name   string
+		entry  string
+		link   string
+		header interface{}
+		valid  bool
+	}{
+		{"file next to link", "b/app", "", &tar.Header{Typeflag: tar.TypeReg}, true},
+		{"file through link", "a/app", "", &tar.Header{Typeflag: tar.TypeReg}, false},
+		{"file through link parent", "a/../app", "", &tar.Header{Typeflag: tar.TypeReg}, false},
+		{"symlink to link", "b", "a", &tar.Header{Typeflag: tar.TypeSymlink}, false},
+		{"symlink through link", "b", "a/..", &tar.Header{Typeflag: tar.TypeSymlink}, false},
+		{"nested symlink through link", "lib/app", "current/app", &tar.Header{Typeflag: tar.TypeSymlink}, false},
+		{"hard link through link", "b", "lib/current/app", &tar.Header{Typeflag: tar.TypeLink}, false},
+		{"symlink next to link", "lib/app", "v1/app", &tar.Header{Typeflag: tar.TypeSymlink}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkEntry(dest, tt.entry, tt.link, tt.header, links)
+			if tt.valid && err != nil {
+				t.Errorf("expected entry '%s' -> '%s' to be valid, got: %s", tt.entry, tt.link, err)
+			}
+			if !tt.valid && err == nil {
+				t.Errorf("expected entry '%s' -> '%s' to be refused", tt.entry, tt.link)
+			}
+		})
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	tests := map[string]string{
+		"app.tar.gz": "tgz",
+		"app.tgz":    "tgz",
+		"app.tar.xz": "txz",
+		"app.tar":    "tar",
+		"app.zip":    "zip",
+		"app.xz":     "xz",
+		"app.jar":    "",
+	}
+	for name, expected := range tests {
+		if format := detectFormat(name); format != expected {
+			t.Errorf("expected format '%s' for '%s', got '%s'", expected, name, format)
+		}
+	}
+}
+
+// writeTgz writes a tgz archive holding given tar headers, regular files
+// being given their name as content
+func writeTgz(t *testing.T, file string, headers []*tar.Header) {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	gz := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gz)
+	for _, h := range headers {
+		content := []byte{}
+		if h.Typeflag == tar.TypeReg {
+			content = []byte(h.Name)
+		}
+		h.Size = int64(len(content))
+		h.Mode = 0644
+		if err := tw.WriteHeader(h); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUnpackFile(t *testing.T) {
+	tests := []struct {
+		name      string
+		headers   []*tar.Header
+		extracted []string
+	}{
+		{
+			name: "valid archive",
+			headers: []*tar.Header{
+				{Name: "bin/", Typeflag: tar.TypeDir},
+				{Name: "bin/app", Typeflag: tar.TypeReg},
+				{Name: "README", Typeflag: tar.TypeReg},
+			},
+			extracted: []string{"bin/app", "README"},
+		},
+		{
+			name: "traversal",
+			headers: []*tar.Header{
+				{Name: "README", Typeflag: tar.TypeReg},
+				{Name: "../evil", Typeflag: tar.TypeReg},
+			},
+		},
+		{
+			name: "absolute symlink",
+			headers: []*tar.Header{
+				{Name: "passwd", Typeflag: tar.TypeSymlink, Linkname: "/etc/passwd"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			dest := filepath.Join(dir, "dest")
+			source := filepath.Join(dir, "app.tgz")
+			writeTgz(t, source, tt.headers)
+
+			extracted, err := unpackFile(source, "tgz", dest)
+			if tt.extracted == nil {
+				if err == nil {
+					t.Fatal("expected archive to be refused")
+				}
+				if _, err := os.Stat(dest); !os.IsNotExist(err) {
+					t.Error("expected nothing to be extracted from a refused archive")
+				}
+				if _, err := os.Stat(filepath.Join(dir, "pwned")); !os.IsNotExist(err) {
+					t.Error("expected nothing to be written outside of destination")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(extracted, tt.extracted) {
+				t.Errorf("expected extracted files %v, got %v", tt.extracted, extracted)
+			}
+			for _, file := range tt.extracted {
+				if _, err := os.Stat(filepath.Join(dest, file)); err != nil {
+					t.Errorf("expected '%s' to be extracted: %s", file, err)
+				}
+			}
+		})
+	}
+}
@@ -38,6 +38,7 @@ func (p Properties) Merge(other Properties) {
 type Version struct {
 	Version string `json:"version"`
 	File    string `json:"file"`
+	Sha256  string `json:"sha256,omitempty"`
 }
 
 type Metadata struct {
@@ -46,40 +47,116 @@ type Metadata struct {
 }
 
 type Source struct {
-	Url        string     `json:"url"`
-	Repository string     `json:"repository"`
-	Filter     string     `json:"filter"`
-	User       string     `json:"user"`
-	Password   string     `json:"password"`
-	ApiKey     string     `json:"apiKey"`
-	SshKey     string     `json:"ssh_key"`
-	LogLevel   string     `json:"log_level"`
-	CACert     string     `json:"ca_cert"`
-	Threads    int        `json:"threads"`
-	Props      Properties `json:"props"`
+	Url              string     `json:"url"`
+	Repository       string     `json:"repository"`
+	Filter           string     `json:"filter"`
+	RelativeFilter   bool       `json:"relative_filter"`
+	Recursive        bool       `json:"recursive"`
+	Exclusions       []string   `json:"exclude_patterns"`
+	FolderVersions   bool       `json:"folder_versions"`
+	GroupFiles       bool       `json:"group_files"`
+	Constraint       string     `json:"version_constraint"`
+	Prerelease       string     `json:"prerelease"`
+	Invalid          string     `json:"invalid_versions"`
+	Sort             []SortKey  `json:"sort"`
+	CalverLayout     string     `json:"calver_layout"`
+	User             string     `json:"user"`
+	Password         string     `json:"password"`
+	ApiKey           string     `json:"apiKey"`
+	AccessToken      string     `json:"access_token"`
+	Oidc             OidcSource `json:"oidc"`
+	Aql              AqlSource  `json:"aql"`
+	SshKey           string     `json:"ssh_key"`
+	LogLevel         string     `json:"log_level"`
+	CACert           string     `json:"ca_cert"`
+	ClientCert       string     `json:"client_cert"`
+	ClientKey        string     `json:"client_key"`
+	GpgPublicKeys    []string   `json:"gpg_public_keys"`
+	StagingPath      string     `json:"staging_path"`
+	CosignPublicKey  string     `json:"cosign_public_key"`
+	CosignPrivateKey string     `json:"cosign_private_key"`
+	CosignPassword   string     `json:"cosign_password"`
+	Threads          int        `json:"threads"`
+	Props            Properties `json:"props"`
+}
+
+type SortKey struct {
+	Group  string `json:"group"`
+	Type   string `json:"type"`
+	Layout string `json:"layout"`
+}
+
+type AqlSource struct {
+	Enabled bool   `json:"enabled"`
+	Query   string `json:"query"`
+	Name    string `json:"name"`
+	Limit   int    `json:"limit"`
+}
+
+// Active tells if check must search files with an aql query, either given or
+// generated from source
+func (a AqlSource) Active() bool {
+	return a.Enabled || a.Query != ""
+}
+
+type OidcSource struct {
+	ProviderName string `json:"provider_name"`
+	Token        string `json:"token"`
+	TokenFile    string `json:"token_file"`
+	TokenEnv     string `json:"token_env"`
+	Audience     string `json:"audience"`
+	ProjectKey   string `json:"project_key"`
+}
+
+func (o OidcSource) Enabled() bool {
+	return o.ProviderName != ""
 }
 
 func (Source) Default() Source {
 	return Source{
-		Filter:   ".*",
-		Threads:  3,
-		Props:    Properties{},
-		LogLevel: "ERROR",
+		Filter:      ".*",
+		Recursive:   true,
+		Threads:     3,
+		Props:       Properties{},
+		LogLevel:    "ERROR",
+		Prerelease:  "include",
+		Invalid:     "log",
+		StagingPath: ".staging",
 	}
 }
 
 type InParams struct {
-	MinSplit      int    `json:"min_split"`
-	SplitCount    int    `json:"split_count"`
-	Destination   string `json:"destination"`
-	PropsFilename string `json:"props_filename"`
+	MinSplit           int       `json:"min_split"`
+	SplitCount         int       `json:"split_count"`
+	Destination        string    `json:"destination"`
+	PropsFilename      string    `json:"props_filename"`
+	PropsFormat        string    `json:"props_format"`
+	VerifyChecksums    bool      `json:"verify_checksums"`
+	SkipDownload       bool      `json:"skip_download"`
+	Unpack             bool      `json:"unpack"`
+	UnpackFormat       string    `json:"unpack_format"`
+	Sidecars           []Sidecar `json:"sidecars"`
+	VerifyGpg          bool      `json:"verify_gpg"`
+	GpgSuffix          string    `json:"gpg_signature_suffix"`
+	VerifyCosign       bool      `json:"verify_cosign"`
+	CosignSuffix       string    `json:"cosign_signature_suffix"`
+	CosignBundleSuffix string    `json:"cosign_bundle_suffix"`
+}
+
+type Sidecar struct {
+	Suffix   string `json:"suffix"`
+	Required bool   `json:"required"`
 }
 
 func (InParams) Default() InParams {
 	return InParams{
-		MinSplit:    5120,
-		SplitCount:  3,
-		Destination: ".",
+		MinSplit:        5120,
+		SplitCount:      3,
+		Destination:     ".",
+		VerifyChecksums: true,
+		GpgSuffix:       ".asc",
+		CosignSuffix:    ".sig",
+		PropsFormat:     "yaml",
 	}
 }
 
@@ -87,11 +164,21 @@ type OutParams struct {
 	Directory     string     `json:"directory"`
 	Props         Properties `json:"props"`
 	PropsFilename string     `json:"props_filename"`
+	Files         []string   `json:"files"`
+	Recursive     bool       `json:"recursive"`
+	Target        string     `json:"target"`
+	VersionFile   string     `json:"version_file"`
+	OnConflict    string     `json:"on_conflict"`
+	Atomic        bool       `json:"atomic"`
+	SetPropsOn    string     `json:"set_props_on"`
+	DeleteProps   []string   `json:"delete_props"`
+	SignCosign    bool       `json:"sign_cosign"`
 }
 
 func (OutParams) Default() OutParams {
 	return OutParams{
-		Props: Properties{},
+		Props:      Properties{},
+		OnConflict: "replace",
 	}
 }
 
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jfrog/jfrog-cli-artifactory/artifactory/commands/generic"
+	"github.com/jfrog/jfrog-cli-core/v2/common/spec"
+	"github.com/orange-cloudfoundry/artifactory-resource/model"
+	"github.com/orange-cloudfoundry/artifactory-resource/utils"
+)
+
+func (c Out) checkAtomic() error {
+	if c.params.Atomic && strings.Trim(c.source.StagingPath, "/") == "" {
+		return errors.New("atomic requires a non empty source staging_path")
+	}
+	return nil
+}
+
+// placement is a staged file moved into place, along with the previous file
+// it replaced, if any
+type placement struct {
+	staged string
+	final  string
+	backup string
+	placed bool
+}
+
+// uploadAtomic uploads files to a staging folder, verifies their checksums
+// and moves them into place so that consumers never see a partial upload.
+// Replaced files are kept aside until all files are in place to be restored
+// on failure. Staging folders are always removed.
+func (c Out) uploadAtomic(filesToSpec *spec.SpecFiles) ([]model.Metadata, error) {
+	id := uuid.NewString()
+	staging := utils.StagingDir(c.source) + "/" + id
+	previous := utils.StagingDir(c.source) + "/" + id + ".previous"
+	repo, _, _ := strings.Cut(staging, "/")
+	defer c.cleanStaging(staging)
+	keepPrevious := false
+	defer func() {
+		if !keepPrevious {
+			c.cleanStaging(previous)
+		}
+	}()
+
+	// stage files at the same path relative to the repository
+	staged := &spec.SpecFiles{Files: []spec.File{}}
+	finals := []string{}
+	for _, f := range filesToSpec.Files {
+		final := f.Target
+		f.Target = staging + "/" + strings.TrimPrefix(final, repo+"/")
+		staged.Files = append(staged.Files, f)
+		finals = append(finals, final)
+	}
+
+	utils.Log("staging files to '%s'...", staging)
+	meta, err := c.upload(staged)
+	if err != nil {
+		return nil, err
+	}
+
+	utils.Log("verifying checksums of staged files...")
+	if err := c.verifyStaged(staged); err != nil {
+		return nil, err
+	}
+
+	utils.Log("moving staged files into place...")
+	placements := []*placement{}
+	for i, f := range staged.Files {
+		p := &placement{staged: f.Target, final: finals[i]}
+		placements = append(placements, p)
+		if err := c.place(p, previous+"/"+strings.TrimPrefix(finals[i], repo+"/")); err != nil {
+			keepPrevious = !c.rollback(placements)
+			return nil, err
+		}
+	}
+	return meta, nil
+}
+
+// place moves a staged file into place, moving aside to backup the file it
+// replaces, if any
+func (c Out) place(p *placement, backup string) error {
+	results, err := utils.Search(c.artdetails, spec.NewBuilder().Pattern(p.final).BuildSpec())
+	if err != nil {
+		return fmt.Errorf("unable to fetch details of '%s': %s", p.final, err)
+	}
+	if len(results) != 0 {
+		if err := c.move(p.final, backup); err != nil {
+			return err
+		}
+		p.backup = backup
+	}
+	if err := c.move(p.staged, p.final); err != nil {
+		return err
+	}
+	p.placed = true
+	return nil
+}
+
+// rollback moves back to staging the files already moved into place, or
+// deletes them when they can't be moved, and restores the files they
+// replaced so that a failed upload leaves the repository untouched. It tells
+// if all replaced files have been restored.
+func (c Out) rollback(placements []*placement) bool {
+	restored := true
+	for i := len(placements) - 1; i >= 0; i-- {
+		p := placements[i]
+		if p.placed {
+			utils.Log("rolling back '%s'...", p.final)
+			if err := c.move(p.final, p.staged); err != nil {
+				if err := c.delete(p.final); err != nil {
+					utils.Log("unable to roll back '%s': %s", p.final, err)
+				}
+			}
+		}
+		if p.backup == "" {
+			continue
+		}
+		utils.Log("restoring previous '%s'...", p.final)
+		if err := c.move(p.backup, p.final); err != nil {
+			utils.Log("unable to restore previous '%s', kept in '%s': %s", p.final, p.backup, err)
+			restored = false
+		}
+	}
+	return restored
+}
+
+func (c Out) move(from string, to string) error {
+	cmd := generic.NewMoveCommand()
+	cmd.SetThreads(c.source.Threads).
+		SetServerDetails(c.artdetails).
+		SetSpec(spec.NewBuilder().Pattern(from).Target(to).Flat(true).BuildSpec())
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("unable to move '%s' to '%s': %s", from, to, err)
+	}
+	if cmd.Result().SuccessCount() != 1 {
+		return fmt.Errorf("unable to move '%s' to '%s'", from, to)
+	}
+	return nil
+}
+
+func (c Out) delete(pattern string) error {
+	cmd := generic.NewDeleteCommand()
+	cmd.SetThreads(c.source.Threads).
+		SetQuiet(true).
+		SetServerDetails(c.artdetails).
+		SetSpec(spec.NewBuilder().Pattern(pattern).Recursive(true).BuildSpec())
+	return cmd.Run()
+}
+
+// verifyStaged ensures each staged file has the sha256 of its local file
+func (c Out) verifyStaged(staged *spec.SpecFiles) error {
+	for _, f := range staged.Files {
+		local, err := utils.HashFile(f.Pattern, sha256.New())
+		if err != nil {
+			return err
+		}
+		results, err := utils.Search(c.artdetails, spec.NewBuilder().Pattern(f.Target).BuildSpec())
+		if err != nil {
+			return fmt.Errorf("unable to fetch details of staged '%s': %s", f.Target, err)
+		}
+		if len(results) != 1 {
+			return fmt.Errorf("staged '%s' not found", f.Target)
+		}
+		if results[0].Sha256 != local {
+			return fmt.Errorf("sha256 mismatch for staged '%s': expected '%s', got '%s'", f.Target, local, results[0].Sha256)
+		}
+	}
+	return nil
+}
+
+func (c Out) cleanStaging(staging string) {
+	utils.Log("removing staging folder '%s'...", staging)
+	if err := c.delete(staging + "/"); err != nil {
+		utils.Log("unable to remove staging folder '%s': %s", staging, err)
+	}
+}
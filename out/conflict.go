@@ -0,0 +1,104 @@
+package main
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/jfrog/jfrog-cli-core/v2/common/spec"
+	"github.com/orange-cloudfoundry/artifactory-resource/model"
+	"github.com/orange-cloudfoundry/artifactory-resource/utils"
+)
+
+const (
+	ON_CONFLICT_REPLACE   = "replace"
+	ON_CONFLICT_FAIL      = "fail"
+	ON_CONFLICT_SKIP      = "skip"
+	ON_CONFLICT_SAME_SKIP = "skip_if_same_checksum"
+)
+
+var onConflictPolicies = []string{ON_CONFLICT_REPLACE, ON_CONFLICT_FAIL, ON_CONFLICT_SKIP, ON_CONFLICT_SAME_SKIP}
+
+func checkOnConflict(policy string) error {
+	if !slices.Contains(onConflictPolicies, policy) {
+		return fmt.Errorf("invalid on_conflict '%s', must be one of %s", policy, strings.Join(onConflictPolicies, ", "))
+	}
+	return nil
+}
+
+// conflicts holds the outcome of on_conflict policy on files already
+// existing in artifactory
+type conflicts struct {
+	// files to upload
+	upload []string
+	// skipped files with the sha256 stored by artifactory, indexed by file
+	skipped map[string]string
+	// existing files that will be replaced
+	replaced []string
+}
+
+// resolveConflicts checks existence of files to upload at their target and
+// applies on_conflict policy
+func (c Out) resolveConflicts(files []string, targets map[string]string) (conflicts, error) {
+	res := conflicts{
+		upload:  []string{},
+		skipped: map[string]string{},
+	}
+	existing := []string{}
+	for _, file := range files {
+		results, err := utils.Search(c.artdetails, spec.NewBuilder().Pattern(targets[file]).BuildSpec())
+		if err != nil {
+			return res, fmt.Errorf("unable to check existence of '%s': %s", targets[file], err)
+		}
+		if len(results) == 0 {
+			res.upload = append(res.upload, file)
+			continue
+		}
+		remote := results[0]
+
+		switch c.params.OnConflict {
+		case ON_CONFLICT_FAIL:
+			existing = append(existing, targets[file])
+			continue
+		case ON_CONFLICT_SKIP:
+			utils.Log("skipping '%s': '%s' already exists", file, targets[file])
+			res.skipped[file] = remote.Sha256
+			continue
+		case ON_CONFLICT_SAME_SKIP:
+			sum, err := utils.HashFile(filepath.Join(utils.BaseDirectory(), c.params.Directory, file), sha256.New())
+			if err != nil {
+				return res, err
+			}
+			if sum == remote.Sha256 {
+				utils.Log("skipping '%s': '%s' already exists with the same sha256", file, targets[file])
+				res.skipped[file] = remote.Sha256
+				continue
+			}
+		}
+		utils.Log("replacing existing '%s'", targets[file])
+		res.replaced = append(res.replaced, targets[file])
+		res.upload = append(res.upload, file)
+	}
+	if len(existing) != 0 {
+		return res, fmt.Errorf("refusing to overwrite existing files: '%s'", strings.Join(existing, "', '"))
+	}
+	return res, nil
+}
+
+func (r conflicts) toMeta(targets map[string]string) []model.Metadata {
+	meta := []model.Metadata{}
+	if len(r.skipped) != 0 {
+		skipped := []string{}
+		for file := range r.skipped {
+			skipped = append(skipped, targets[file])
+		}
+		slices.Sort(skipped)
+		meta = append(meta, model.Metadata{Name: "skipped", Value: strings.Join(skipped, ", ")})
+	}
+	if len(r.replaced) != 0 {
+		meta = append(meta, model.Metadata{Name: "replaced", Value: strings.Join(r.replaced, ", ")})
+	}
+	return meta
+}
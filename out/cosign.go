@@ -0,0 +1,68 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/jfrog/jfrog-cli-core/v2/common/spec"
+	"github.com/orange-cloudfoundry/artifactory-resource/utils"
+)
+
+// COSIGN_SUFFIX is the suffix of signatures uploaded next to each file, as
+// expected by cosign verify-blob
+const COSIGN_SUFFIX = ".sig"
+
+func (c Out) checkCosign() error {
+	if !c.params.SignCosign {
+		return nil
+	}
+	if c.source.CosignPrivateKey == "" {
+		return errors.New("sign_cosign requires source cosign_private_key")
+	}
+	return utils.CheckCosignPrivateKey(c.source.CosignPrivateKey, c.source.CosignPassword)
+}
+
+// signCosign signs given files with source private key and returns the spec
+// uploading signatures next to them. Signatures are written in the returned
+// temporary directory to keep the uploaded directory untouched, caller must
+// remove it once uploaded.
+func (c Out) signCosign(files []string, targets map[string]string) (*spec.SpecFiles, string, error) {
+	dir, err := os.MkdirTemp("", "cosign")
+	if err != nil {
+		return nil, "", err
+	}
+	res, err := c.writeSignatures(dir, files, targets)
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, "", err
+	}
+	return res, dir, nil
+}
+
+func (c Out) writeSignatures(dir string, files []string, targets map[string]string) (*spec.SpecFiles, error) {
+	res := &spec.SpecFiles{
+		Files: []spec.File{},
+	}
+	for _, file := range files {
+		utils.Log("signing '%s' with cosign...", file)
+		sig, err := utils.CosignSign(c.source.CosignPrivateKey, c.source.CosignPassword, filepath.Join(utils.BaseDirectory(), c.params.Directory, file))
+		if err != nil {
+			return nil, fmt.Errorf("unable to sign '%s': %s", file, err)
+		}
+		sigPath := filepath.Join(dir, file+COSIGN_SUFFIX)
+		if err := os.MkdirAll(filepath.Dir(sigPath), os.ModePerm); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(sigPath, []byte(sig), 0644); err != nil {
+			return nil, err
+		}
+		buildSpec := spec.NewBuilder().
+			Pattern(sigPath).
+			Target(targets[file] + COSIGN_SUFFIX).
+			BuildSpec()
+		res.Files = append(res.Files, buildSpec.Files...)
+	}
+	return res, nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestGlobToRegexp(t *testing.T) {
+	tests := []struct {
+		glob    string
+		path    string
+		matches bool
+	}{
+		{"*.tgz", "app.tgz", true},
+		{"*.tgz", "sub/app.tgz", false},
+		{"**/*.tgz", "app.tgz", true},
+		{"**/*.tgz", "sub/dir/app.tgz", true},
+		{"**/*.tgz", "app.tgz.sha256", false},
+		{"bin/*", "bin/app", true},
+		{"bin/*", "bin/sub/app", false},
+		{"bin/**", "bin/sub/app", true},
+		{"a/**/b.txt", "a/b.txt", true},
+		{"a/**/b.txt", "a/x/y/b.txt", true},
+		{"a/**/b.txt", "ab.txt", false},
+		{"app-?.tgz", "app-1.tgz", true},
+		{"app-?.tgz", "app-12.tgz", false},
+		{"app-?.tgz", "app-/.tgz", false},
+		{"app-[0-9].tgz", "app-7.tgz", true},
+		{"app-[!0-9].tgz", "app-7.tgz", false},
+		{"app-[!0-9].tgz", "app-x.tgz", true},
+		{"app.(1).tgz", "app.(1).tgz", true},
+		{"app.(1).tgz", "appx(1).tgz", false},
+	}
+	for _, tt := range tests {
+		re, err := globToRegexp(tt.glob)
+		if err != nil {
+			t.Fatalf("unexpected error for glob '%s': %s", tt.glob, err)
+		}
+		if matches := re.MatchString(tt.path); matches != tt.matches {
+			t.Errorf("expected glob '%s' matching '%s' to be %t", tt.glob, tt.path, tt.matches)
+		}
+	}
+}
+
+func TestGlobToRegexpInvalid(t *testing.T) {
+	for _, glob := range []string{"app-[0-9.tgz", "app-[z-a].tgz"} {
+		if _, err := globToRegexp(glob); err == nil {
+			t.Errorf("expected glob '%s' to be refused", glob)
+		}
+	}
+}
@@ -1,9 +1,14 @@
 package main
 
 import (
+	"crypto/sha256"
 	"fmt"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"time"
 
 	"github.com/jfrog/jfrog-cli-artifactory/artifactory/commands/generic"
@@ -43,6 +48,18 @@ func (c *Out) Run() {
 	if err != nil {
 		utils.Fatal(err.Error())
 	}
+	if c.source.FolderVersions {
+		utils.Fatal("folder_versions is not supported by out")
+	}
+	if err := checkOnConflict(c.params.OnConflict); err != nil {
+		utils.Fatal(err.Error())
+	}
+	if err := c.checkAtomic(); err != nil {
+		utils.Fatal(err.Error())
+	}
+	if err := c.checkCosign(); err != nil {
+		utils.Fatal(err.Error())
+	}
 
 	c.artdetails, err = utils.RetrieveArtDetails(c.source)
 	if err != nil {
@@ -51,35 +68,67 @@ func (c *Out) Run() {
 
 	c.source.Repository = utils.AddTrailingSlashIfNeeded(c.source.Repository)
 	props := c.mergeProps()
+	if c.params.SetPropsOn != "" {
+		version, meta, err := c.updateProps(props)
+		if err != nil {
+			utils.Fatal(err.Error())
+		}
+		err = utils.SendJsonResponse(model.Response{
+			Metadata: meta,
+			Version:  version,
+		})
+		if err != nil {
+			utils.Log(fmt.Sprintf("error sending request to artifactory: %s", err.Error()))
+		}
+		return
+	}
 	toUpload := c.getUploadFiles()
-	filesToSpec := c.filesToSpec(toUpload, props)
+	if c.source.GroupFiles {
+		c.checkGroup(toUpload)
+	}
+	targets, err := c.targets(toUpload)
+	if err != nil {
+		utils.Fatal(err.Error())
+	}
+	conflicts, err := c.resolveConflicts(toUpload, targets)
+	if err != nil {
+		utils.Fatal(err.Error())
+	}
+	filesToSpec := c.filesToSpec(conflicts.upload, targets, props)
 
 	// upload
-	for _, s := range filesToSpec.Files {
-		utils.Log("uploading '%s' to '%s'...", s.Pattern, c.source.Repository)
-	}
 	startDl := time.Now()
-	origStdout := os.Stdout
-	os.Stdout = os.Stderr
-	meta, err := c.upload(filesToSpec)
-	os.Stdout = origStdout
+	meta, err := c.signAndUpload(filesToSpec, conflicts.upload, targets)
 	if err != nil {
-		utils.Fatal("error when uploading: %s", err)
+		utils.Fatal(err.Error())
 	}
 	elapsed := time.Since(startDl)
-	utils.Log("finished uploading files to '%s'", c.source.Repository)
+	meta = append(meta, conflicts.toMeta(targets)...)
 
 	// use last file as version info
-	filter := utils.NewFilter(c.source.Filter)
+	filter := utils.NewSourceFilter(c.source)
 	ts := time.Now().Format(utils.TS_FORMAT)
 	version := model.Version{}
+	checksums := map[string]string{}
 	for _, file := range toUpload {
-		_, key := filter.Match(file, ts)
+		_, key := filter.Match(file, ts, ts)
+		sum, _ := utils.HashFile(filepath.Join(utils.BaseDirectory(), c.params.Directory, file), sha256.New())
+		// skipped files keep the content stored by artifactory
+		if remoteSum, ok := conflicts.skipped[file]; ok {
+			sum = remoteSum
+		}
+		checksums[path.Base(file)] = sum
 		version = model.Version{
-			File:    filepath.Join(c.source.Repository, file),
+			File:    targets[file],
 			Version: key,
+			Sha256:  sum,
 		}
 	}
+	// all files of a group make the version
+	if c.source.GroupFiles {
+		version.File = ""
+		version.Sha256 = utils.GroupChecksum(checksums)
+	}
 
 	meta = append(meta, model.Metadata{
 		Name:  "elapsed",
@@ -95,6 +144,41 @@ func (c *Out) Run() {
 	}
 }
 
+// signAndUpload signs given files when requested and uploads them along with
+// their signatures, temporary signatures being removed whatever the outcome
+func (c Out) signAndUpload(filesToSpec *spec.SpecFiles, files []string, targets map[string]string) ([]model.Metadata, error) {
+	if c.params.SignCosign {
+		signatures, sigDir, err := c.signCosign(files, targets)
+		if err != nil {
+			return nil, fmt.Errorf("error when signing: %s", err)
+		}
+		defer os.RemoveAll(sigDir)
+		filesToSpec.Files = append(filesToSpec.Files, signatures.Files...)
+	}
+	if len(filesToSpec.Files) == 0 {
+		return []model.Metadata{}, nil
+	}
+
+	for _, s := range filesToSpec.Files {
+		utils.Log("uploading '%s' to '%s'...", s.Pattern, s.Target)
+	}
+	origStdout := os.Stdout
+	os.Stdout = os.Stderr
+	var meta []model.Metadata
+	var err error
+	if c.params.Atomic {
+		meta, err = c.uploadAtomic(filesToSpec)
+	} else {
+		meta, err = c.upload(filesToSpec)
+	}
+	os.Stdout = origStdout
+	if err != nil {
+		return nil, fmt.Errorf("error when uploading: %s", err)
+	}
+	utils.Log("finished uploading files to '%s'", c.source.Repository)
+	return meta, nil
+}
+
 func (c Out) getFilePath(p string) string {
 	src := utils.AddTrailingSlashIfNeeded(utils.BaseDirectory())
 	src += utils.RemoveStartingSlashIfNeeded(p)
@@ -120,21 +204,49 @@ func (c Out) upload(spec *spec.SpecFiles) ([]model.Metadata, error) {
 	return utils.TransfertDetailsToMeta(cmd.Result()), nil
 }
 
+// getUploadFiles lists files of directory, or of its whole tree when
+// recursive or globs are given, matching globs and source filter. Files are
+// returned as slash separated paths relative to directory.
 func (c Out) getUploadFiles() []string {
-	files, err := os.ReadDir(filepath.Join(utils.BaseDirectory(), c.params.Directory))
-	if err != nil {
-		utils.Fatal(fmt.Sprintf("could not list files in directory '%s': %s", c.params.Directory, err))
+	globs := []*regexp.Regexp{}
+	for _, glob := range c.params.Files {
+		re, err := globToRegexp(glob)
+		if err != nil {
+			utils.Fatal(err.Error())
+		}
+		globs = append(globs, re)
 	}
 
-	filter := utils.NewFilter(c.source.Filter)
+	root := filepath.Join(utils.BaseDirectory(), c.params.Directory)
+	walkTree := c.params.Recursive || len(globs) != 0
+	filter := utils.NewSourceFilter(c.source)
 	ts := time.Now().Format(utils.TS_FORMAT)
 	res := []string{}
-	for _, file := range files {
-		match, _ := filter.Match(file.Name(), ts)
-		if !match {
-			continue
+	err := filepath.WalkDir(root, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if file != root && !walkTree {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(root, file)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if len(globs) != 0 && !slices.ContainsFunc(globs, func(re *regexp.Regexp) bool { return re.MatchString(rel) }) {
+			return nil
 		}
-		res = append(res, file.Name())
+		if match, _ := filter.Match(rel, ts, ts); match {
+			res = append(res, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		utils.Fatal(fmt.Sprintf("could not list files in directory '%s': %s", c.params.Directory, err))
 	}
 
 	if len(res) == 0 {
@@ -144,7 +256,19 @@ func (c Out) getUploadFiles() []string {
 	return res
 }
 
-func (c Out) filesToSpec(files []string, props model.Properties) *spec.SpecFiles {
+// checkGroup ensures all files to upload share the same version
+func (c Out) checkGroup(files []string) {
+	filter := utils.NewSourceFilter(c.source)
+	ts := time.Now().Format(utils.TS_FORMAT)
+	_, first := filter.Match(files[0], ts, ts)
+	for _, file := range files[1:] {
+		if _, key := filter.Match(file, ts, ts); key != first {
+			utils.Fatal(fmt.Sprintf("files to upload must share the same version when grouping files, found '%s' for '%s' and '%s' for '%s'", first, files[0], key, file))
+		}
+	}
+}
+
+func (c Out) filesToSpec(files []string, targets map[string]string, props model.Properties) *spec.SpecFiles {
 	res := &spec.SpecFiles{
 		Files: []spec.File{},
 	}
@@ -154,9 +278,8 @@ func (c Out) filesToSpec(files []string, props model.Properties) *spec.SpecFiles
 		builder := spec.NewBuilder()
 		buildSpec := builder.
 			Pattern(absPath).
-			Target(c.source.Repository).
+			Target(targets[file]).
 			Props(props.String()).
-			Flat(true).
 			BuildSpec()
 		res.Files = append(res.Files, buildSpec.Files...)
 	}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/jfrog/jfrog-cli-artifactory/artifactory/commands/generic"
+	artutils "github.com/jfrog/jfrog-cli-core/v2/artifactory/utils"
+	"github.com/jfrog/jfrog-cli-core/v2/common/spec"
+	"github.com/orange-cloudfoundry/artifactory-resource/model"
+	"github.com/orange-cloudfoundry/artifactory-resource/utils"
+)
+
+// fetched reads the version and files of a previous get from its version
+// and metadata.json files, found in the info directory of the get given by
+// params.set_props_on
+func (c Out) fetched() (model.Version, []artutils.SearchResult, error) {
+	dir := c.getFilePath(c.params.SetPropsOn)
+	if info, err := os.Stat(dir); err != nil {
+		return model.Version{}, nil, fmt.Errorf("could not find '%s': %s", c.params.SetPropsOn, err)
+	} else if !info.IsDir() {
+		dir = filepath.Dir(dir)
+	}
+	if filepath.Base(dir) != utils.INFO_DIR {
+		dir = filepath.Join(dir, utils.INFO_DIR)
+	}
+
+	version, err := os.ReadFile(filepath.Join(dir, "version"))
+	if err != nil {
+		return model.Version{}, nil, fmt.Errorf("could not read version of '%s': %s", c.params.SetPropsOn, err)
+	}
+	content, err := os.ReadFile(filepath.Join(dir, "metadata.json"))
+	if err != nil {
+		return model.Version{}, nil, fmt.Errorf("could not read metadata of '%s': %s", c.params.SetPropsOn, err)
+	}
+
+	// metadata of grouped versions is a list of files
+	files := []artutils.SearchResult{}
+	if bytes.HasPrefix(bytes.TrimSpace(content), []byte("[")) {
+		err = json.Unmarshal(content, &files)
+	} else {
+		file := artutils.SearchResult{}
+		err = json.Unmarshal(content, &file)
+		files = append(files, file)
+	}
+	if err != nil {
+		return model.Version{}, nil, fmt.Errorf("invalid metadata of '%s': %s", c.params.SetPropsOn, err)
+	}
+	if len(files) == 0 || files[0].Path == "" {
+		return model.Version{}, nil, fmt.Errorf("no file found in metadata of '%s'", c.params.SetPropsOn)
+	}
+
+	res := model.Version{
+		Version: strings.TrimSpace(string(version)),
+		File:    files[0].Path,
+		Sha256:  files[0].Sha256,
+	}
+	if c.source.GroupFiles {
+		checksums := map[string]string{}
+		for _, file := range files {
+			checksums[path.Base(file.Path)] = file.Sha256
+		}
+		res.File = ""
+		res.Sha256 = utils.GroupChecksum(checksums)
+	}
+	return res, files, nil
+}
+
+// updateProps sets and deletes properties of files of a previous get
+// instead of uploading, returning the version of these files
+func (c Out) updateProps(props model.Properties) (model.Version, []model.Metadata, error) {
+	version, files, err := c.fetched()
+	if err != nil {
+		return version, nil, err
+	}
+
+	paths := []string{}
+	for _, file := range files {
+		spc := spec.NewBuilder().
+			Pattern(file.Path).
+			BuildSpec()
+		if len(props) != 0 {
+			utils.Log("setting properties '%s' on '%s'...", props.String(), file.Path)
+			cmd := generic.NewSetPropsCommand()
+			cmd.SetPropsCommand(*c.propsCommand(spc, props.String()))
+			if err := runPropsCommand(cmd.Run, cmd.Result().SuccessCount, file.Path); err != nil {
+				return version, nil, fmt.Errorf("unable to set properties on '%s': %s", file.Path, err)
+			}
+		}
+		if len(c.params.DeleteProps) != 0 {
+			utils.Log("deleting properties '%s' of '%s'...", strings.Join(c.params.DeleteProps, "', '"), file.Path)
+			cmd := generic.NewDeletePropsCommand()
+			cmd.DeletePropsCommand(*c.propsCommand(spc, strings.Join(c.params.DeleteProps, ",")))
+			if err := runPropsCommand(cmd.Run, cmd.Result().SuccessCount, file.Path); err != nil {
+				return version, nil, fmt.Errorf("unable to delete properties of '%s': %s", file.Path, err)
+			}
+		}
+		paths = append(paths, file.Path)
+	}
+
+	meta := []model.Metadata{{Name: "updated", Value: strings.Join(paths, ", ")}}
+	if len(props) != 0 {
+		meta = append(meta, model.Metadata{Name: "props set", Value: props.String()})
+	}
+	if len(c.params.DeleteProps) != 0 {
+		meta = append(meta, model.Metadata{Name: "props deleted", Value: strings.Join(c.params.DeleteProps, ", ")})
+	}
+	return version, meta, nil
+}
+
+func (c Out) propsCommand(spc *spec.SpecFiles, props string) *generic.PropsCommand {
+	cmd := generic.NewPropsCommand()
+	cmd.SetProps(props).
+		SetThreads(c.source.Threads).
+		SetServerDetails(c.artdetails).
+		SetSpec(spc)
+	return cmd
+}
+
+// runPropsCommand runs a properties command, failing when the file has not
+// been updated
+func runPropsCommand(run func() error, successCount func() int, file string) error {
+	origStdout := os.Stdout
+	os.Stdout = os.Stderr
+	err := run()
+	os.Stdout = origStdout
+	if err != nil {
+		return err
+	}
+	if successCount() == 0 {
+		return fmt.Errorf("'%s' not found", file)
+	}
+	return nil
+}
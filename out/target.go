@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/orange-cloudfoundry/artifactory-resource/utils"
+)
+
+// VERSION_FILE_PLACEHOLDER is replaced by the content of params.version_file
+const VERSION_FILE_PLACEHOLDER = "version_file"
+
+// matches '{name}' placeholders, '${NAME}' and '$NAME' env vars
+var templateRe = regexp.MustCompile(`\{(\w+)\}|\$\{(\w+)\}|\$(\w+)`)
+
+// isBuildEnv tells if given env var is one of the build metadata given by
+// concourse, the only ones available to templates to avoid leaking secrets
+func isBuildEnv(name string) bool {
+	return strings.HasPrefix(name, "BUILD_") || name == "ATC_EXTERNAL_URL"
+}
+
+// targets returns the artifactory path of each file to upload, indexed by
+// file, rendering params.target for each of them under source repository
+func (c Out) targets(files []string) (map[string]string, error) {
+	versionFile := ""
+	if c.params.VersionFile != "" {
+		content, err := os.ReadFile(c.getFilePath(c.params.VersionFile))
+		if err != nil {
+			return nil, fmt.Errorf("could not read version from file '%s': %s", c.params.VersionFile, err)
+		}
+		versionFile = strings.TrimSpace(string(content))
+	}
+
+	filter := regexp.MustCompile(c.source.Filter)
+	res := map[string]string{}
+	for _, file := range files {
+		target, err := c.renderTarget(filter, file, versionFile)
+		if err != nil {
+			return nil, err
+		}
+		res[file] = path.Join(c.source.Repository, target, file)
+	}
+	return res, nil
+}
+
+// renderTarget replaces placeholders of params.target by named or numbered
+// captures of source filter on given file, the content of version file and
+// build env vars
+func (c Out) renderTarget(filter *regexp.Regexp, file string, versionFile string) (string, error) {
+	matches := filter.FindStringSubmatch(file)
+	var err error
+	target := templateRe.ReplaceAllStringFunc(c.params.Target, func(placeholder string) string {
+		groups := templateRe.FindStringSubmatch(placeholder)
+		if name := groups[1]; name != "" {
+			if name == VERSION_FILE_PLACEHOLDER && c.params.VersionFile != "" {
+				return versionFile
+			}
+			idx := filter.SubexpIndex(name)
+			if n, convErr := strconv.Atoi(name); convErr == nil {
+				idx = n
+			}
+			if idx < 0 || idx >= len(matches) || matches[idx] == "" {
+				err = fmt.Errorf("placeholder '%s' of target '%s' has no value for file '%s'", placeholder, c.params.Target, file)
+				return placeholder
+			}
+			return matches[idx]
+		}
+		name := groups[2] + groups[3]
+		if !isBuildEnv(name) {
+			err = fmt.Errorf("env var '%s' of target '%s' is not a build env var", name, c.params.Target)
+			return placeholder
+		}
+		return os.Getenv(name)
+	})
+	if err != nil {
+		return "", err
+	}
+	target = utils.RemoveStartingSlashIfNeeded(path.Clean("/" + target))
+	return target, nil
+}
@@ -0,0 +1,209 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	COSIGN_ENCRYPTED_KEY_TYPE  = "ENCRYPTED SIGSTORE PRIVATE KEY"
+	COSIGN_LEGACY_KEY_TYPE     = "ENCRYPTED COSIGN PRIVATE KEY"
+	COSIGN_SECRETBOX_KEY_SIZE  = 32
+	COSIGN_SECRETBOX_NONCE_LEN = 24
+)
+
+// cosignEncryptedKey is the json content of cosign encrypted private keys
+type cosignEncryptedKey struct {
+	Kdf struct {
+		Name   string `json:"name"`
+		Params struct {
+			N int `json:"N"`
+			R int `json:"r"`
+			P int `json:"p"`
+		} `json:"params"`
+		Salt []byte `json:"salt"`
+	} `json:"kdf"`
+	Cipher struct {
+		Name  string `json:"name"`
+		Nonce []byte `json:"nonce"`
+	} `json:"cipher"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// cosignBundle holds the signature of both legacy cosign bundles and
+// sigstore bundles
+type cosignBundle struct {
+	Base64Signature  string `json:"base64Signature"`
+	MessageSignature struct {
+		Signature string `json:"signature"`
+	} `json:"messageSignature"`
+}
+
+func CheckCosignPublicKey(publicKey string) error {
+	_, err := parseCosignPublicKey(publicKey)
+	return err
+}
+
+func CheckCosignPrivateKey(privateKey string, password string) error {
+	_, err := parseCosignPrivateKey(privateKey, password)
+	return err
+}
+
+func parseCosignPublicKey(publicKey string) (*ecdsa.PublicKey, error) {
+	block, _ := pem.Decode([]byte(publicKey))
+	if block == nil {
+		return nil, errors.New("invalid cosign public key: no PEM data found")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cosign public key: %s", err)
+	}
+	ecKey, ok := key.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, errors.New("invalid cosign public key: only ECDSA keys are supported")
+	}
+	return ecKey, nil
+}
+
+// parseCosignPrivateKey reads a cosign encrypted private key, decrypted with
+// given password, or an unencrypted PEM ECDSA private key
+func parseCosignPrivateKey(privateKey string, password string) (*ecdsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(privateKey))
+	if block == nil {
+		return nil, errors.New("invalid cosign private key: no PEM data found")
+	}
+
+	der := block.Bytes
+	switch block.Type {
+	case COSIGN_ENCRYPTED_KEY_TYPE, COSIGN_LEGACY_KEY_TYPE:
+		var err error
+		der, err = decryptCosignKey(block.Bytes, password)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cosign private key: %s", err)
+		}
+	case "EC PRIVATE KEY":
+		key, err := x509.ParseECPrivateKey(der)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cosign private key: %s", err)
+		}
+		return key, nil
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cosign private key: %s", err)
+	}
+	ecKey, ok := key.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("invalid cosign private key: only ECDSA keys are supported")
+	}
+	return ecKey, nil
+}
+
+func decryptCosignKey(content []byte, password string) ([]byte, error) {
+	encrypted := cosignEncryptedKey{}
+	if err := json.Unmarshal(content, &encrypted); err != nil {
+		return nil, err
+	}
+	if encrypted.Kdf.Name != "scrypt" || encrypted.Cipher.Name != "nacl/secretbox" {
+		return nil, fmt.Errorf("unsupported encryption '%s' with '%s'", encrypted.Kdf.Name, encrypted.Cipher.Name)
+	}
+	if len(encrypted.Cipher.Nonce) != COSIGN_SECRETBOX_NONCE_LEN {
+		return nil, errors.New("invalid nonce length")
+	}
+	params := encrypted.Kdf.Params
+	derived, err := scrypt.Key([]byte(password), encrypted.Kdf.Salt, params.N, params.R, params.P, COSIGN_SECRETBOX_KEY_SIZE)
+	if err != nil {
+		return nil, err
+	}
+	var key [COSIGN_SECRETBOX_KEY_SIZE]byte
+	var nonce [COSIGN_SECRETBOX_NONCE_LEN]byte
+	copy(key[:], derived)
+	copy(nonce[:], encrypted.Cipher.Nonce)
+	der, ok := secretbox.Open(nil, encrypted.Ciphertext, &nonce, &key)
+	if !ok {
+		return nil, errors.New("decryption failed, wrong password")
+	}
+	return der, nil
+}
+
+func hashFileSha256(file string) ([]byte, error) {
+	sum, err := HashFile(file, sha256.New())
+	if err != nil {
+		return nil, err
+	}
+	return hex.DecodeString(sum)
+}
+
+// CosignSign signs given file as cosign sign-blob does and returns the base64
+// encoded signature
+func CosignSign(privateKey string, password string, file string) (string, error) {
+	key, err := parseCosignPrivateKey(privateKey, password)
+	if err != nil {
+		return "", err
+	}
+	digest, err := hashFileSha256(file)
+	if err != nil {
+		return "", err
+	}
+	sig, err := key.Sign(rand.Reader, digest, crypto.SHA256)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(sig), nil
+}
+
+// CosignVerify verifies the base64 encoded signature of given file, as
+// produced by cosign sign-blob, against given public key
+func CosignVerify(publicKey string, file string, signature string) error {
+	key, err := parseCosignPublicKey(publicKey)
+	if err != nil {
+		return err
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(signature))
+	if err != nil {
+		return fmt.Errorf("invalid signature encoding: %s", err)
+	}
+	digest, err := hashFileSha256(file)
+	if err != nil {
+		return err
+	}
+	if !ecdsa.VerifyASN1(key, digest, sig) {
+		return errors.New("invalid signature")
+	}
+	return nil
+}
+
+// CosignBundleSignature reads the base64 encoded signature from given cosign
+// or sigstore bundle file
+func CosignBundleSignature(file string) (string, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	bundle := cosignBundle{}
+	if err := json.Unmarshal(content, &bundle); err != nil {
+		return "", fmt.Errorf("invalid bundle: %s", err)
+	}
+	if bundle.Base64Signature != "" {
+		return bundle.Base64Signature, nil
+	}
+	if bundle.MessageSignature.Signature != "" {
+		return bundle.MessageSignature.Signature, nil
+	}
+	return "", errors.New("invalid bundle: no signature found")
+}
@@ -0,0 +1,347 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver"
+	"github.com/orange-cloudfoundry/artifactory-resource/model"
+)
+
+const (
+	// separates values of each sort key in composite versions
+	KEY_SEPARATOR = "|"
+
+	SORT_SEMVER   = "semver"
+	SORT_NUMERIC  = "numeric"
+	SORT_CALVER   = "calver"
+	SORT_ASC      = "asc"
+	SORT_DESC     = "desc"
+	SORT_CREATED  = "created"
+	SORT_MODIFIED = "modified"
+)
+
+var sortTypes = []string{SORT_SEMVER, SORT_NUMERIC, SORT_CALVER, SORT_ASC, SORT_DESC, SORT_CREATED, SORT_MODIFIED}
+
+// sortKey is a typed value extracted from a file, either from a named group
+// of the filter or from artifactory timestamps
+type sortKey struct {
+	name   string
+	index  int
+	typ    string
+	layout string
+}
+
+func (k sortKey) fromGroup() bool {
+	return k.typ != SORT_CREATED && k.typ != SORT_MODIFIED
+}
+
+type Filter struct {
+	re         *regexp.Regexp
+	keys       []sortKey
+	prerelease string
+	invalid    string
+}
+
+// NewSourceFilter creates a filter from source, honoring its sort keys,
+// prerelease and invalid versions policies
+func NewSourceFilter(source model.Source) *Filter {
+	f := NewFilter(source.Filter)
+	f.prerelease = source.Prerelease
+	f.invalid = source.Invalid
+	for i := range f.keys {
+		if f.keys[i].typ == SORT_CALVER {
+			f.keys[i].layout = source.CalverLayout
+		}
+	}
+	if len(source.Sort) != 0 {
+		f.keys = []sortKey{}
+		for _, k := range source.Sort {
+			f.keys = append(f.keys, sortKey{
+				name:   k.Group,
+				index:  f.re.SubexpIndex(k.Group),
+				typ:    k.Type,
+				layout: k.Layout,
+			})
+		}
+	}
+	return f
+}
+
+func NewFilter(filter string) *Filter {
+	f := &Filter{
+		re:   regexp.MustCompile(filter),
+		keys: []sortKey{{index: -1, typ: SORT_MODIFIED}},
+	}
+	modes := map[string]string{
+		"version": SORT_SEMVER,
+		"asc":     SORT_ASC,
+		"desc":    SORT_DESC,
+		"num":     SORT_NUMERIC,
+		"calver":  SORT_CALVER,
+	}
+	for _, key := range []string{"version", "asc", "desc", "num", "calver"} {
+		if idx := f.re.SubexpIndex(key); idx != -1 {
+			f.keys = []sortKey{{name: key, index: idx, typ: modes[key]}}
+			break
+		}
+	}
+	return f
+}
+
+func checkSortKeys(source model.Source) error {
+	re := regexp.MustCompile(source.Filter)
+	if len(source.Sort) == 0 {
+		for _, k := range NewFilter(source.Filter).keys {
+			if k.typ != SORT_CALVER {
+				continue
+			}
+			if err := checkLayout(source.CalverLayout); err != nil {
+				return fmt.Errorf("invalid calver_layout '%s' required by '?P<calver>' named group: %s", source.CalverLayout, err)
+			}
+		}
+	}
+	for _, k := range source.Sort {
+		if !slices.Contains(sortTypes, k.Type) {
+			return fmt.Errorf("invalid sort type '%s', must be one of %s", k.Type, strings.Join(sortTypes, ", "))
+		}
+		key := sortKey{typ: k.Type}
+		if !key.fromGroup() {
+			continue
+		}
+		if re.SubexpIndex(k.Group) == -1 {
+			return fmt.Errorf("sort group '%s' is not a named group of filter '%s'", k.Group, source.Filter)
+		}
+		if k.Type != SORT_CALVER {
+			continue
+		}
+		if err := checkLayout(k.Layout); err != nil {
+			return fmt.Errorf("invalid layout '%s' of calver sort group '%s': %s", k.Layout, k.Group, err)
+		}
+	}
+	return nil
+}
+
+// checkLayout ensures given Go time layout holds at least one date element
+// and can parse the dates it formats
+func checkLayout(layout string) error {
+	if layout == "" {
+		return errors.New("layout must not be empty")
+	}
+	ref := time.Date(2024, time.November, 23, 21, 34, 56, 0, time.UTC)
+	formatted := ref.Format(layout)
+	if formatted == layout {
+		return errors.New("layout does not contain any date element")
+	}
+	if _, err := time.Parse(layout, formatted); err != nil {
+		return err
+	}
+	return nil
+}
+
+// HasSemver tells if at least one of the sort keys is a semver
+func (f *Filter) HasSemver() bool {
+	for _, k := range f.keys {
+		if k.typ == SORT_SEMVER {
+			return true
+		}
+	}
+	return false
+}
+
+// FromGroups tells if all sort keys are extracted from filter named groups,
+// files sharing the same captures having then the same version
+func (f *Filter) FromGroups() bool {
+	for _, k := range f.keys {
+		if !k.fromGroup() {
+			return false
+		}
+	}
+	return true
+}
+
+// Semver returns the value of the first semver sort key of given version
+func (f *Filter) Semver(key string) string {
+	values := f.split(key)
+	for i, k := range f.keys {
+		if k.typ == SORT_SEMVER {
+			return values[i]
+		}
+	}
+	return ""
+}
+
+// TimeKey returns the artifactory field and value of given version when the
+// first sort key is a timestamp, versions being ordered on it first
+func (f *Filter) TimeKey(key string) (string, string, bool) {
+	if f.keys[0].fromGroup() {
+		return "", "", false
+	}
+	return f.keys[0].typ, f.split(key)[0], true
+}
+
+func (f *Filter) Match(name string, created_at string, modified_at string) (bool, string) {
+	matches := f.re.FindStringSubmatch(name)
+	if matches == nil {
+		return false, ""
+	}
+	values := []string{}
+	for _, k := range f.keys {
+		val := ""
+		switch k.typ {
+		case SORT_CREATED:
+			val = created_at
+		case SORT_MODIFIED:
+			val = modified_at
+		default:
+			val = matches[k.index]
+		}
+		if val == "" {
+			return false, ""
+		}
+		values = append(values, val)
+	}
+	return true, strings.Join(values, KEY_SEPARATOR)
+}
+
+// Accept tells if given key, extracted from file name, must be considered as a
+// version according to prerelease and invalid versions policies
+func (f *Filter) Accept(name string, key string) (bool, error) {
+	values := f.split(key)
+	for i, k := range f.keys {
+		if err := k.check(values[i]); err != nil {
+			switch f.invalid {
+			case "error":
+				return false, fmt.Errorf("invalid %s '%s' for file '%s': %s", k.typ, values[i], name, err)
+			case "log":
+				Log("ignoring file '%s': invalid %s '%s': %s", name, k.typ, values[i], err)
+			}
+			return false, nil
+		}
+		if k.typ != SORT_SEMVER {
+			continue
+		}
+		sv, _ := semver.NewVersion(values[i])
+		if f.prerelease == "exclude" && sv.Prerelease() != "" {
+			return false, nil
+		}
+		if f.prerelease == "only" && sv.Prerelease() == "" {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (f *Filter) Less(v1 string, v2 string) bool {
+	values1 := f.split(v1)
+	values2 := f.split(v2)
+	for i, k := range f.keys {
+		if cmp := k.compare(values1[i], values2[i]); cmp != 0 {
+			return cmp == -1
+		}
+	}
+	return false
+}
+
+// split returns values of each sort key from given version, missing values
+// being left empty
+func (f *Filter) split(key string) []string {
+	values := strings.SplitN(key, KEY_SEPARATOR, len(f.keys))
+	for len(values) < len(f.keys) {
+		values = append(values, "")
+	}
+	return values
+}
+
+// compare returns -1, 0 or 1 when v1 is respectively lower, equal or greater
+// than v2. Values that can't be parsed are greater than any other.
+func (k sortKey) compare(v1 string, v2 string) int {
+	switch k.typ {
+	case SORT_CREATED, SORT_MODIFIED:
+		return compareParsed(v1, v2, parseTs, func(t1, t2 time.Time) int { return t1.Compare(t2) })
+
+	case SORT_CALVER:
+		parse := func(v string) (time.Time, error) { return time.Parse(k.layout, v) }
+		return compareParsed(v1, v2, parse, func(t1, t2 time.Time) int { return t1.Compare(t2) })
+
+	case SORT_NUMERIC:
+		return compareParsed(v1, v2, parseNumeric, compareNumeric)
+
+	case SORT_ASC:
+		return strings.Compare(v1, v2)
+
+	case SORT_DESC:
+		return strings.Compare(v2, v1)
+
+	case SORT_SEMVER:
+		return compareParsed(v1, v2, semver.NewVersion, func(sv1, sv2 *semver.Version) int {
+			// build metadata is ignored by semver precedence, use it as tiebreak
+			// to keep ordering stable
+			if cmp := sv1.Compare(sv2); cmp != 0 {
+				return cmp
+			}
+			return strings.Compare(sv1.Metadata(), sv2.Metadata())
+		})
+	}
+	return 0
+}
+
+// check ensures given value can be parsed according to the key type, asc,
+// desc and timestamps from artifactory being always valid
+func (k sortKey) check(v string) error {
+	var err error
+	switch k.typ {
+	case SORT_SEMVER:
+		_, err = semver.NewVersion(v)
+	case SORT_NUMERIC:
+		_, err = parseNumeric(v)
+	case SORT_CALVER:
+		_, err = time.Parse(k.layout, v)
+	}
+	return err
+}
+
+func compareParsed[T any](v1 string, v2 string, parse func(string) (T, error), cmp func(T, T) int) int {
+	p1, err1 := parse(v1)
+	p2, err2 := parse(v2)
+	switch {
+	case err1 != nil && err2 != nil:
+		return 0
+	case err1 != nil:
+		return 1
+	case err2 != nil:
+		return -1
+	}
+	return cmp(p1, p2)
+}
+
+func parseTs(v string) (time.Time, error) {
+	ts, err := time.Parse(TS_FORMAT, v)
+	if err != nil {
+		return time.Parse(time.RFC3339, v)
+	}
+	return ts, nil
+}
+
+// parseNumeric validates given value is a positive integer of arbitrary
+// length and returns it without leading zeros
+func parseNumeric(v string) (string, error) {
+	if v == "" || strings.Trim(v, "0123456789") != "" {
+		return "", fmt.Errorf("'%s' is not a positive integer", v)
+	}
+	return strings.TrimLeft(v, "0"), nil
+}
+
+func compareNumeric(n1 string, n2 string) int {
+	if len(n1) != len(n2) {
+		if len(n1) < len(n2) {
+			return -1
+		}
+		return 1
+	}
+	return strings.Compare(n1, n2)
+}
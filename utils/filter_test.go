@@ -0,0 +1,241 @@
+package utils
+
+import (
+	"slices"
+	"sort"
+	"testing"
+
+	"github.com/orange-cloudfoundry/artifactory-resource/model"
+)
+
+const TEST_TS = "2024-06-12T15:30:00.000Z"
+
+// sortNames orders given file names from oldest to newest version, failing
+// when a name does not match the filter or is not accepted as a version
+func sortNames(t *testing.T, f *Filter, names []string) []string {
+	t.Helper()
+	keys := map[string]string{}
+	for _, name := range names {
+		match, key := f.Match(name, TEST_TS, TEST_TS)
+		if !match {
+			t.Fatalf("expected '%s' to match filter", name)
+		}
+		if accept, err := f.Accept(name, key); !accept || err != nil {
+			t.Fatalf("expected '%s' to be accepted as a version: %v", name, err)
+		}
+		keys[name] = key
+	}
+	res := slices.Clone(names)
+	sort.SliceStable(res, func(i, j int) bool {
+		return f.Less(keys[res[i]], keys[res[j]])
+	})
+	return res
+}
+
+func TestFilterLegacyGroups(t *testing.T) {
+	tests := []struct {
+		name     string
+		filter   string
+		expected []string
+	}{
+		{
+			name:     "semver",
+			filter:   `app-(?P<version>.*)\.tgz`,
+			expected: []string{"app-1.2.0-rc.1.tgz", "app-1.2.0.tgz", "app-1.10.0.tgz"},
+		},
+		{
+			name:     "asc",
+			filter:   `app-(?P<asc>.*)\.tgz`,
+			expected: []string{"app-a.tgz", "app-b.tgz", "app-c.tgz"},
+		},
+		{
+			name:     "desc",
+			filter:   `app-(?P<desc>.*)\.tgz`,
+			expected: []string{"app-c.tgz", "app-b.tgz", "app-a.tgz"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			names := slices.Clone(tt.expected)
+			slices.Reverse(names)
+			sorted := sortNames(t, NewFilter(tt.filter), names)
+			if !slices.Equal(sorted, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, sorted)
+			}
+		})
+	}
+}
+
+func TestFilterCompositeKeys(t *testing.T) {
+	source := model.Source{}.Default()
+	source.Filter = `stemcell-(?P<major>[0-9.]+)-build(?P<build>[0-9]+)\.tgz`
+	source.Sort = []model.SortKey{
+		{Group: "major", Type: SORT_SEMVER},
+		{Group: "build", Type: SORT_NUMERIC},
+		{Type: SORT_MODIFIED},
+	}
+	if err := checkSortKeys(source); err != nil {
+		t.Fatal(err)
+	}
+	f := NewSourceFilter(source)
+
+	expected := []string{
+		"stemcell-1.9-build42.tgz",
+		"stemcell-1.234-build9.tgz",
+		"stemcell-1.234-build10.tgz",
+		"stemcell-2.0-build1.tgz",
+	}
+	names := slices.Clone(expected)
+	slices.Reverse(names)
+	if sorted := sortNames(t, f, names); !slices.Equal(sorted, expected) {
+		t.Errorf("expected %v, got %v", expected, sorted)
+	}
+
+	_, key := f.Match("stemcell-1.234-build10.tgz", TEST_TS, TEST_TS)
+	if key != "1.234|10|"+TEST_TS {
+		t.Errorf("unexpected composite key '%s'", key)
+	}
+	if f.FromGroups() {
+		t.Error("expected modified key not to come from groups")
+	}
+	if !f.HasSemver() || f.Semver(key) != "1.234" {
+		t.Errorf("expected semver '1.234' in key '%s'", key)
+	}
+
+	// timestamps break ties between files sharing the same captures
+	older, newer := "1.234|10|2024-06-12T15:30:00.000Z", "1.234|10|2024-06-13T15:30:00.000Z"
+	if !f.Less(older, newer) || f.Less(newer, older) {
+		t.Errorf("expected '%s' to be older than '%s'", older, newer)
+	}
+}
+
+func TestCheckSortKeys(t *testing.T) {
+	tests := []struct {
+		name  string
+		sort  []model.SortKey
+		valid bool
+	}{
+		{"valid", []model.SortKey{{Group: "major", Type: SORT_SEMVER}, {Type: SORT_CREATED}}, true},
+		{"unknown type", []model.SortKey{{Group: "major", Type: "lexical"}}, false},
+		{"unknown group", []model.SortKey{{Group: "minor", Type: SORT_SEMVER}}, false},
+		{"timestamps need no group", []model.SortKey{{Type: SORT_MODIFIED}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := model.Source{}.Default()
+			source.Filter = `app-(?P<major>[0-9.]+)\.tgz`
+			source.Sort = tt.sort
+			err := checkSortKeys(source)
+			if tt.valid && err != nil {
+				t.Errorf("expected sort keys to be valid, got: %s", err)
+			}
+			if !tt.valid && err == nil {
+				t.Error("expected sort keys to be refused")
+			}
+		})
+	}
+}
+
+func TestFilterNumeric(t *testing.T) {
+	f := NewFilter(`build-(?P<num>.*)\.tgz`)
+	expected := []string{"build-2.tgz", "build-9.tgz", "build-10.tgz", "build-99999999999999999999.tgz"}
+	names := slices.Clone(expected)
+	slices.Reverse(names)
+	if sorted := sortNames(t, f, names); !slices.Equal(sorted, expected) {
+		t.Errorf("expected %v, got %v", expected, sorted)
+	}
+	if !f.Less("9", "10") || f.Less("10", "9") {
+		t.Error("expected 9 to be lower than 10")
+	}
+	if f.Less("010", "10") || f.Less("10", "010") {
+		t.Error("expected leading zeros to be ignored")
+	}
+}
+
+func TestFilterCalver(t *testing.T) {
+	tests := []struct {
+		name     string
+		layout   string
+		expected []string
+	}{
+		{
+			name:     "dotted",
+			layout:   "2006.01.2",
+			expected: []string{"app-2023.12.31.tgz", "app-2024.06.1.tgz", "app-2024.06.12.tgz"},
+		},
+		{
+			name:     "timestamp",
+			layout:   "20060102-1504",
+			expected: []string{"app-20240612-0930.tgz", "app-20240612-1530.tgz", "app-20240613-0000.tgz"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := model.Source{}.Default()
+			source.Filter = `app-(?P<calver>.*)\.tgz`
+			source.CalverLayout = tt.layout
+			if err := checkSortKeys(source); err != nil {
+				t.Fatal(err)
+			}
+			names := slices.Clone(tt.expected)
+			slices.Reverse(names)
+			if sorted := sortNames(t, NewSourceFilter(source), names); !slices.Equal(sorted, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, sorted)
+			}
+		})
+	}
+}
+
+func TestFilterAcceptInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter string
+		file   string
+	}{
+		{"semver", `app-(?P<version>.*)\.tgz`, "app-invalid.tgz"},
+		{"numeric", `build-(?P<num>.*)\.tgz`, "build-x.tgz"},
+		{"negative numeric", `build-(?P<num>.*)\.tgz`, "build--1.tgz"},
+		{"calver", `app-(?P<calver>.*)\.tgz`, "app-2024.13.1.tgz"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := model.Source{}.Default()
+			source.Filter = tt.filter
+			source.CalverLayout = "2006.01.2"
+
+			for _, policy := range []string{"skip", "log", "error"} {
+				source.Invalid = policy
+				f := NewSourceFilter(source)
+				match, key := f.Match(tt.file, TEST_TS, TEST_TS)
+				if !match {
+					t.Fatalf("expected '%s' to match filter", tt.file)
+				}
+				accept, err := f.Accept(tt.file, key)
+				if accept {
+					t.Errorf("expected '%s' to be rejected with policy '%s'", tt.file, policy)
+				}
+				if (policy == "error") != (err != nil) {
+					t.Errorf("unexpected error with policy '%s': %v", policy, err)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckLayout(t *testing.T) {
+	tests := map[string]bool{
+		"2006.01.2":     true,
+		"20060102-1504": true,
+		"":              false,
+		"build":         false,
+	}
+	for layout, valid := range tests {
+		err := checkLayout(layout)
+		if valid && err != nil {
+			t.Errorf("expected layout '%s' to be valid, got: %s", layout, err)
+		}
+		if !valid && err == nil {
+			t.Errorf("expected layout '%s' to be refused", layout)
+		}
+	}
+}
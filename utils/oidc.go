@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	artutils "github.com/jfrog/jfrog-cli-core/v2/artifactory/utils"
+	"github.com/jfrog/jfrog-cli-core/v2/utils/config"
+	"github.com/jfrog/jfrog-client-go/access/services"
+	"github.com/orange-cloudfoundry/artifactory-resource/model"
+)
+
+const (
+	OIDC_GRANT_TYPE         = "urn:ietf:params:oauth:grant-type:token-exchange"
+	OIDC_SUBJECT_TOKEN_TYPE = "urn:ietf:params:oauth:token-type:id_token"
+)
+
+// oidcAccessToken caches the access token obtained from the oidc exchange
+// for the life of the current step
+var oidcAccessToken string
+
+func checkOidcParams(oidc model.OidcSource) error {
+	if !oidc.Enabled() {
+		return nil
+	}
+	if oidc.Token == "" && oidc.TokenFile == "" && oidc.TokenEnv == "" {
+		return errors.New("you must pass one of oidc.token, oidc.token_file or oidc.token_env when oidc.provider_name is given")
+	}
+	return nil
+}
+
+// readOidcToken returns the JWT given inline, from a file or from an
+// environment variable, in that order of precedence
+func readOidcToken(oidc model.OidcSource) (string, error) {
+	if oidc.Token != "" {
+		return strings.TrimSpace(oidc.Token), nil
+	}
+	if oidc.TokenFile != "" {
+		path := oidc.TokenFile
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(BaseDirectory(), path)
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("unable to read oidc token file '%s': %s", oidc.TokenFile, err)
+		}
+		return strings.TrimSpace(string(content)), nil
+	}
+	token := strings.TrimSpace(os.Getenv(oidc.TokenEnv))
+	if token == "" {
+		return "", fmt.Errorf("environment variable '%s' does not contain any oidc token", oidc.TokenEnv)
+	}
+	return token, nil
+}
+
+// exchangeOidcToken trades the JWT provided by concourse for a short-lived
+// artifactory access token
+func exchangeOidcToken(details *config.ServerDetails, oidc model.OidcSource) (string, error) {
+	if oidcAccessToken != "" {
+		return oidcAccessToken, nil
+	}
+	token, err := readOidcToken(oidc)
+	if err != nil {
+		return "", err
+	}
+	manager, err := artutils.CreateAccessServiceManager(details, false)
+	if err != nil {
+		return "", err
+	}
+	res, err := manager.ExchangeOidcToken(services.CreateOidcTokenParams{
+		GrantType:        OIDC_GRANT_TYPE,
+		SubjectTokenType: OIDC_SUBJECT_TOKEN_TYPE,
+		OidcTokenID:      token,
+		ProviderName:     oidc.ProviderName,
+		Audience:         oidc.Audience,
+		ProjectKey:       oidc.ProjectKey,
+	})
+	if err != nil {
+		return "", fmt.Errorf("unable to exchange oidc token with provider '%s': %s", oidc.ProviderName, err)
+	}
+	if res.AccessToken == "" {
+		return "", fmt.Errorf("oidc provider '%s' returned an empty access token", oidc.ProviderName)
+	}
+	oidcAccessToken = res.AccessToken
+	return oidcAccessToken, nil
+}
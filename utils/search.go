@@ -0,0 +1,114 @@
+package utils
+
+import (
+	"strings"
+
+	"github.com/jfrog/jfrog-cli-artifactory/artifactory/commands/generic"
+	artutils "github.com/jfrog/jfrog-cli-core/v2/artifactory/utils"
+	"github.com/jfrog/jfrog-cli-core/v2/common/spec"
+	"github.com/jfrog/jfrog-cli-core/v2/utils/config"
+	"github.com/orange-cloudfoundry/artifactory-resource/model"
+)
+
+// RepositorySpec builds the search spec listing files of source repository
+func RepositorySpec(source model.Source) *spec.SpecFiles {
+	return spec.NewBuilder().
+		Pattern(AddTrailingSlashIfNeeded(source.Repository)).
+		Props(source.Props.String()).
+		Recursive(source.Recursive).
+		Exclusions(source.Exclusions).
+		BuildSpec()
+}
+
+// FolderSpec builds the search spec listing immediate sub-directories, along
+// with files, of source repository
+func FolderSpec(source model.Source) *spec.SpecFiles {
+	return spec.NewBuilder().
+		Pattern(AddTrailingSlashIfNeeded(source.Repository)).
+		Props(source.Props.String()).
+		Recursive(false).
+		IncludeDirs(true).
+		Exclusions(source.Exclusions).
+		BuildSpec()
+}
+
+// FolderFiles returns all files found in given folder and its sub-directories
+func FolderFiles(details *config.ServerDetails, folder string) ([]artutils.SearchResult, error) {
+	spc := spec.NewBuilder().
+		Pattern(AddTrailingSlashIfNeeded(folder)).
+		Recursive(true).
+		BuildSpec()
+	return Search(details, spc)
+}
+
+// FilterPath returns the path of given file that source filter must match
+func FilterPath(source model.Source, path string) string {
+	if !source.RelativeFilter {
+		return path
+	}
+	repository := RemoveStartingSlashIfNeeded(AddTrailingSlashIfNeeded(source.Repository))
+	return strings.TrimPrefix(path, repository)
+}
+
+// Search runs given search spec and returns all found files
+func Search(details *config.ServerDetails, spec *spec.SpecFiles) ([]artutils.SearchResult, error) {
+	res := []artutils.SearchResult{}
+	cmd := generic.NewSearchCommand()
+	cmd.
+		SetServerDetails(details).
+		SetSpec(spec)
+
+	err := cmd.Run()
+	if err != nil {
+		return nil, err
+	}
+
+	reader := cmd.Result().Reader()
+	defer CloseAndLogError(reader)
+	_, err = reader.Length()
+	if err != nil {
+		return nil, err
+	}
+
+	for val := new(artutils.SearchResult); reader.NextRecord(val) == nil; val = new(artutils.SearchResult) {
+		res = append(res, *val)
+	}
+
+	return res, nil
+}
+
+// GroupFiles returns paths of all files of source repository belonging to
+// given version
+func GroupFiles(details *config.ServerDetails, source model.Source, version string) ([]string, error) {
+	results, err := Search(details, RepositorySpec(source))
+	if err != nil {
+		return nil, err
+	}
+	filter := NewSourceFilter(source)
+	res := []string{}
+	for _, file := range results {
+		if InStaging(source, file.Path) {
+			continue
+		}
+		match, key := filter.Match(FilterPath(source, file.Path), file.Created, file.Modified)
+		if match && key == version {
+			res = append(res, file.Path)
+		}
+	}
+	return res, nil
+}
+
+// StagingDir returns the folder of source repository where atomic uploads are
+// staged before being moved into place
+func StagingDir(source model.Source) string {
+	repo, _, _ := strings.Cut(strings.Trim(source.Repository, "/"), "/")
+	return repo + "/" + strings.Trim(source.StagingPath, "/")
+}
+
+// InStaging tells if given path is a staged file, or the staging folder
+// itself, which must not be seen as versions
+func InStaging(source model.Source, path string) bool {
+	staging := StagingDir(source)
+	path = RemoveStartingSlashIfNeeded(path)
+	return path == staging || strings.HasPrefix(path, staging+"/")
+}
@@ -3,6 +3,7 @@ package utils
 import (
 	"crypto/md5"
 	"crypto/sha1"
+	"crypto/sha256"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -11,8 +12,9 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"sort"
 	"strings"
-	"time"
 
 	"github.com/Masterminds/semver"
 
@@ -24,9 +26,13 @@ import (
 	"github.com/orange-cloudfoundry/artifactory-resource/model"
 )
 
+var constraintRe = regexp.MustCompile(`([<>=!~^]*)\s*([^\s,<>=!~^]+)`)
+
 const (
 	ART_SECURITY_FOLDER = "security/"
 	TS_FORMAT           = "2006-01-02T15:04:05.000Z"
+	// directory of in destination holding files describing the fetched version
+	INFO_DIR = ".artifactory-resource"
 )
 
 func CheckReqParamsWithPattern(source model.Source) error {
@@ -40,12 +46,94 @@ func CheckReqParams(source model.Source) error {
 	if source.Url == "" {
 		return errors.New("you must pass an url to artifactory")
 	}
-	if source.User == "" && source.ApiKey == "" {
-		return errors.New("you must pass user/password pair or apiKey to authnticate over artifactory")
+	if err := checkCredentials(source); err != nil {
+		return err
+	}
+	if (source.ClientCert == "") != (source.ClientKey == "") {
+		return errors.New("client_cert and client_key must be given together")
 	}
 	if _, err := regexp.Compile(source.Filter); err != nil {
 		return fmt.Errorf("invalid filter '%s', must be valid regexp: %s", source.Filter, err)
 	}
+	if !slices.Contains([]string{"include", "exclude", "only"}, source.Prerelease) {
+		return fmt.Errorf("invalid prerelease '%s', must be one of include, exclude or only", source.Prerelease)
+	}
+	if !slices.Contains([]string{"error", "skip", "log"}, source.Invalid) {
+		return fmt.Errorf("invalid invalid_versions '%s', must be one of error, skip or log", source.Invalid)
+	}
+	if source.Aql.Limit < 0 {
+		return fmt.Errorf("invalid aql.limit '%d', must be positive", source.Aql.Limit)
+	}
+	if err := checkSortKeys(source); err != nil {
+		return err
+	}
+	if source.GroupFiles && !NewSourceFilter(source).FromGroups() {
+		return fmt.Errorf("group_files requires versions extracted from named groups of filter '%s' only", source.Filter)
+	}
+	if source.FolderVersions && source.GroupFiles {
+		return errors.New("folder_versions can't be used along with group_files")
+	}
+	if source.FolderVersions && source.Aql.Active() {
+		return errors.New("folder_versions can't be used along with aql")
+	}
+	if source.Constraint != "" {
+		if !NewSourceFilter(source).HasSemver() {
+			return fmt.Errorf("version_constraint requires a '?P<version>' named group or a semver sort key in filter '%s'", source.Filter)
+		}
+		if _, err := NewVersionConstraint(source.Constraint); err != nil {
+			return fmt.Errorf("invalid version_constraint '%s': %s", source.Constraint, err)
+		}
+	}
+	return nil
+}
+
+// NewVersionConstraint parses given semver range, space separated
+// conditions (e.g. '>=1.0 <2.0') being accepted as comma separated ones
+func NewVersionConstraint(constraint string) (*semver.Constraints, error) {
+	ors := []string{}
+	for _, or := range strings.Split(constraint, "||") {
+		if strings.Contains(or, " - ") {
+			ors = append(ors, or)
+			continue
+		}
+		ands := []string{}
+		for _, cond := range constraintRe.FindAllStringSubmatch(or, -1) {
+			ands = append(ands, cond[1]+cond[2])
+		}
+		ors = append(ors, strings.Join(ands, ", "))
+	}
+	return semver.NewConstraint(strings.Join(ors, " || "))
+}
+
+// checkCredentials ensures that exactly one authentication mode is configured
+// among user/password, user/apiKey, access_token and oidc, ssh_key and client
+// certificate being allowed alone or along with another mode.
+func checkCredentials(source model.Source) error {
+	modes := 0
+	if source.Password != "" {
+		modes++
+	}
+	if source.ApiKey != "" {
+		modes++
+	}
+	if source.AccessToken != "" {
+		modes++
+	}
+	if source.Oidc.Enabled() {
+		modes++
+	}
+	if modes == 0 && source.SshKey == "" && source.ClientCert == "" {
+		return errors.New("you must pass user/password pair, user/apiKey pair, access_token or oidc to authenticate over artifactory")
+	}
+	if modes > 1 {
+		return errors.New("only one of password, apiKey, access_token or oidc must be given to authenticate over artifactory")
+	}
+	if err := checkOidcParams(source.Oidc); err != nil {
+		return err
+	}
+	if (source.Password != "" || source.ApiKey != "") && source.User == "" {
+		return errors.New("you must pass user along with password or apiKey to authenticate over artifactory")
+	}
 	return nil
 }
 
@@ -54,14 +142,29 @@ func RetrieveArtDetails(source model.Source) (*config.ServerDetails, error) {
 	if err != nil {
 		return nil, err
 	}
+	certPath, keyPath, err := createClientCert(source.ClientCert, source.ClientKey)
+	if err != nil {
+		return nil, err
+	}
 	sshKeyPath, err := createSshKeyPath(source.SshKey)
-	return &config.ServerDetails{
-		ArtifactoryUrl: AddTrailingSlashIfNeeded(source.Url),
-		Url:            AddTrailingSlashIfNeeded(source.Url),
-		User:           source.User,
-		Password:       source.Password,
-		SshKeyPath:     sshKeyPath,
-	}, err
+	details := &config.ServerDetails{
+		ArtifactoryUrl:    AddTrailingSlashIfNeeded(source.Url),
+		Url:               AddTrailingSlashIfNeeded(source.Url),
+		User:              source.User,
+		Password:          source.Password,
+		AccessToken:       source.AccessToken,
+		SshKeyPath:        sshKeyPath,
+		ClientCertPath:    certPath,
+		ClientCertKeyPath: keyPath,
+	}
+	// artifactory accepts api keys as basic auth password
+	if source.ApiKey != "" {
+		details.Password = source.ApiKey
+	}
+	if err == nil && source.Oidc.Enabled() {
+		details.AccessToken, err = exchangeOidcToken(details, source.Oidc)
+	}
+	return details, err
 }
 
 func AddTrailingSlashIfNeeded(path string) string {
@@ -93,6 +196,29 @@ func createCert(caCert string) error {
 	return os.WriteFile(securityPath+"cert.pem", []byte(caCert), 0644)
 }
 
+func createClientCert(clientCert string, clientKey string) (string, string, error) {
+	if clientCert == "" {
+		return "", "", nil
+	}
+	confPath, err := coreutils.GetJfrogHomeDir()
+	if err != nil {
+		return "", "", err
+	}
+	securityPath := filepath.Join(confPath, ART_SECURITY_FOLDER)
+	if err := os.MkdirAll(securityPath, os.ModePerm); err != nil {
+		return "", "", err
+	}
+	certPath := filepath.Join(securityPath, "client-cert.pem")
+	keyPath := filepath.Join(securityPath, "client-key.pem")
+	if err := os.WriteFile(certPath, []byte(clientCert), 0644); err != nil {
+		return "", "", err
+	}
+	if err := os.WriteFile(keyPath, []byte(clientKey), 0600); err != nil {
+		return "", "", err
+	}
+	return certPath, keyPath, nil
+}
+
 func createSshKeyPath(sshKey string) (string, error) {
 	if sshKey == "" {
 		return "", nil
@@ -132,6 +258,25 @@ func HashFile(path string, hasher hash.Hash) (string, error) {
 	return fmt.Sprintf("%x", hasher.Sum(nil)), nil
 }
 
+// GroupChecksum computes a single sha256 from the sha256 of each file of a
+// group, formatted as sha256sum output sorted by file name. Returns an empty
+// string when the checksum of any file is unknown.
+func GroupChecksum(checksums map[string]string) string {
+	names := []string{}
+	for name, sum := range checksums {
+		if sum == "" {
+			return ""
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	hasher := sha256.New()
+	for _, name := range names {
+		fmt.Fprintf(hasher, "%s  %s\n", checksums[name], name)
+	}
+	return fmt.Sprintf("%x", hasher.Sum(nil))
+}
+
 func TransfertDetailsToMeta(result *cmdutils.Result) []model.Metadata {
 	metadata := []model.Metadata{}
 	if result != nil && result.Reader() != nil {
@@ -161,75 +306,6 @@ func TransfertDetailsToMeta(result *cmdutils.Result) []model.Metadata {
 	return metadata
 }
 
-type Filter struct {
-	re    *regexp.Regexp
-	index int
-	mode  string
-}
-
-func NewFilter(filter string) *Filter {
-	f := &Filter{
-		re:    regexp.MustCompile(filter),
-		index: -1,
-		mode:  "ts",
-	}
-	for _, key := range []string{"version", "asc", "desc"} {
-		if idx := f.re.SubexpIndex(key); idx != -1 {
-			f.mode = key
-			f.index = idx
-			break
-		}
-	}
-	return f
-}
-
-func (f *Filter) Match(name string, modified_at string) (bool, string) {
-	matches := f.re.FindStringSubmatch(name)
-	if matches == nil {
-		return false, ""
-	}
-	key := ""
-	if f.mode != "ts" {
-		key = matches[f.index]
-	} else {
-		key = modified_at
-	}
-	return key != "", key
-}
-
-func (f *Filter) Less(v1 string, v2 string) bool {
-	switch f.mode {
-	case "ts":
-		ts1, err := time.Parse(TS_FORMAT, v1)
-		if err != nil {
-			return false
-		}
-		ts2, err := time.Parse(TS_FORMAT, v2)
-		if err != nil {
-			return true
-		}
-		return ts1.Before(ts2)
-
-	case "asc":
-		return strings.Compare(v1, v2) == -1
-
-	case "desc":
-		return strings.Compare(v2, v1) == -1
-
-	case "version":
-		sv1, err := semver.NewVersion(v1)
-		if err != nil {
-			return false
-		}
-		sv2, err := semver.NewVersion(v2)
-		if err != nil {
-			return true
-		}
-		return sv1.Compare(sv2) == -1
-	}
-	return false
-}
-
 func BaseDirectory() string {
 	directory, _ := os.Getwd()
 	if len(os.Args) >= 2 {
@@ -0,0 +1,139 @@
+package utils
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	artutils "github.com/jfrog/jfrog-cli-core/v2/artifactory/utils"
+	"github.com/jfrog/jfrog-cli-core/v2/utils/coreutils"
+	"github.com/orange-cloudfoundry/artifactory-resource/model"
+)
+
+// newCert creates a certificate signed by parent, self-signed when parent is
+// nil, and returns it with its PEM encoded content and key
+func newCert(t *testing.T, cn string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey, string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		parent, parentKey = tmpl, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPem := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPem := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+	return cert, key, string(certPem), string(keyPem)
+}
+
+func TestRetrieveArtDetailsClientCert(t *testing.T) {
+	t.Setenv(coreutils.HomeDir, t.TempDir())
+
+	ca, caKey, _, _ := newCert(t, "test-ca", nil, nil)
+	_, _, clientCert, clientKey := newCert(t, "client", ca, caKey)
+	_, _, otherCert, otherKey := newCert(t, "other", nil, nil)
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"version": "7.0.0"}`))
+	}))
+	server.TLS = &tls.Config{
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  clientCAs,
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	// trust the server certificate where artifactory clients look for CAs
+	certsDir, err := coreutils.GetJfrogCertsDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(certsDir, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	serverCert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(filepath.Join(certsDir, "server.pem"), serverCert, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		clientCert string
+		clientKey  string
+		success    bool
+	}{
+		{"trusted client cert", clientCert, clientKey, true},
+		{"no client cert", "", "", false},
+		{"untrusted client cert", otherCert, otherKey, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			details, err := RetrieveArtDetails(model.Source{
+				Url:         server.URL + "/artifactory",
+				AccessToken: "token",
+				ClientCert:  tt.clientCert,
+				ClientKey:   tt.clientKey,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.clientCert != "" {
+				home, _ := coreutils.GetJfrogHomeDir()
+				if filepath.Dir(filepath.Dir(details.ClientCertPath)) != home {
+					t.Errorf("client cert '%s' must be written in jfrog home '%s'", details.ClientCertPath, home)
+				}
+			}
+
+			manager, err := artutils.CreateServiceManager(details, 0, 0, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			version, err := manager.GetVersion()
+			if tt.success && err != nil {
+				t.Fatalf("request failed with client cert: %s", err)
+			}
+			if !tt.success && err == nil {
+				t.Fatal("request succeeded without a trusted client cert")
+			}
+			if tt.success && version != "7.0.0" {
+				t.Errorf("expected version '7.0.0', got '%s'", version)
+			}
+		})
+	}
+}